@@ -3,18 +3,33 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/kyeliu99/Pflow_v2/backend/internal/attachment"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/auth"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bulk"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/camunda"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
 	httpserver "github.com/kyeliu99/Pflow_v2/backend/internal/http"
+	attachmenthttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/attachment"
+	bulkhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/bulk"
 	flowhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/flow"
+	migrationhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/migration"
+	outboxhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/outbox"
+	slahttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/sla"
+	taskhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/task"
 	workorderhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/workorder"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/migration"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/mq"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/outbox"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence/migrations"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/sla"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/task"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
 )
 
@@ -24,6 +39,26 @@ func main() {
 		log.Fatalf("load config: %v", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), cfg, os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		log.Fatalf("configure auth: %v", err)
+	}
+	if !cfg.Auth.Enabled {
+		log.Printf("auth disabled: all requests run as anonymous admin")
+	}
+
+	blobStore, err := attachment.NewBlobStore(context.Background(), cfg.Attachments)
+	if err != nil {
+		log.Fatalf("configure attachment store: %v", err)
+	}
+
 	db, err := persistence.NewDatabase(cfg.Database)
 	if err != nil {
 		log.Fatalf("connect database: %v", err)
@@ -34,6 +69,17 @@ func main() {
 		}
 	}()
 
+	if cfg.Database.AutoMigrate {
+		applied, err := persistence.NewMigrator(db.DB, migrations.FS).Up(context.Background())
+		for _, m := range applied {
+			log.Printf("applied migration %s", m)
+		}
+		if err != nil {
+			db.Close()
+			log.Fatalf("migrate database: %v", err)
+		}
+	}
+
 	publisher, err := mq.NewPublisher(cfg.Queue)
 	if err != nil {
 		log.Printf("queue disabled: %v", err)
@@ -46,24 +92,58 @@ func main() {
 		}()
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	outboxRepo := outbox.NewRepository(db.DB)
+	events := outbox.NewWriter(outboxRepo)
+	relay := mq.NewRelay(outboxRepo, db, publisher, cfg.Outbox)
+	go relay.Run(ctx)
+
 	camundaClient := camunda.NewClient(cfg.Camunda)
 	runtime := camunda.NewRuntime(camundaClient.HTTP())
 
 	flowRepo := flow.NewRepository(db.DB)
-	flowService := flow.NewService(flowRepo, camundaClient, publisher)
+	flowService := flow.NewService(flowRepo, db, camundaClient, events, cfg.Camunda.Retry)
 
 	workorderRepo := workorder.NewRepository(db.DB)
 	flowReader := workorder.FlowServiceAdapter{Service: flowService}
-	workorderService := workorder.NewService(workorderRepo, flowReader, runtime, publisher)
+	slaService := sla.NewService(sla.NewRepository(db.DB), flowReader)
+	workorderService := workorder.NewService(workorderRepo, db, flowReader, runtime, events, slaService, cfg.Camunda.Retry)
+
+	attachmentService := attachment.NewService(attachment.NewRepository(db.DB), blobStore, workorderService, runtime, cfg.Attachments)
+
+	bulkRunner := bulk.NewRunner(bulk.NewRepository(db.DB), db, workorderService, cfg.Bulk)
+	go bulkRunner.Run(ctx)
+
+	migrator := migration.NewMigrator(migration.NewRepository(db.DB), db, flowService, workorderService, runtime, cfg.Migration)
+	go migrator.Run(ctx)
+
+	taskService := task.NewService(runtime, workorderService, flowService)
 
-	server := httpserver.NewServer(cfg,
+	deployRetrier := flow.NewDeployRetrier(flowRepo, db, camundaClient, cfg.Camunda.Retry)
+	go deployRetrier.Run(ctx)
+
+	startRetrier := workorder.NewStartRetrier(workorderRepo, db, flowReader, runtime, cfg.Camunda.Retry)
+	go startRetrier.Run(ctx)
+
+	synchronizer := workorder.NewSynchronizer(workorderRepo, db, runtime, events, cfg.Camunda.SyncInterval, cfg.Camunda.SyncBatchSize)
+	go synchronizer.Run(ctx)
+
+	slaMonitor := workorder.NewSLAMonitor(workorderRepo, db, events, cfg.SLA.CheckInterval, cfg.SLA.BatchSize)
+	go slaMonitor.Run(ctx)
+
+	server := httpserver.NewServer(cfg, authenticator,
 		flowhttp.Handlers{Service: flowService},
+		migrationhttp.Handlers{Service: migrator},
 		workorderhttp.Handlers{Service: workorderService},
+		attachmenthttp.Handlers{Service: attachmentService, MaxSize: cfg.Attachments.MaxSize},
+		bulkhttp.Handlers{Service: bulkRunner},
+		taskhttp.Handlers{Service: taskService},
+		slahttp.Handlers{Service: slaService},
+		outboxhttp.Handlers{Service: relay},
 	)
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
 	errCh := make(chan error, 1)
 	go func() {
 		errCh <- server.Run()
@@ -0,0 +1,33 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+type Blob interface {
+	io.ReadSeekCloser
+}
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (Blob, error)
+	Delete(ctx context.Context, key string) error
+}
+
+func NewBlobStore(ctx context.Context, cfg config.AttachmentConfig) (BlobStore, error) {
+	switch cfg.Store {
+	case "", "local":
+		return NewLocalStore(cfg.Local.Dir)
+	case "s3":
+		return NewS3Store(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unsupported attachment store %q", cfg.Store)
+	}
+}
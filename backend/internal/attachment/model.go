@@ -0,0 +1,37 @@
+package attachment
+
+import "time"
+
+const ProcessVariable = "attachments"
+
+type Attachment struct {
+	ID          string    `json:"id" db:"id"`
+	WorkOrderID string    `json:"workOrderId" db:"workorder_id"`
+	FileName    string    `json:"fileName" db:"file_name"`
+	ContentType string    `json:"contentType" db:"content_type"`
+	Size        int64     `json:"size" db:"size_bytes"`
+	Checksum    string    `json:"checksum" db:"checksum"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	UploadedBy  string    `json:"uploadedBy" db:"uploaded_by"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+type Reference struct {
+	ID          string `json:"id"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	URL         string `json:"url"`
+}
+
+func (a Attachment) Reference() Reference {
+	return Reference{
+		ID:          a.ID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Checksum:    a.Checksum,
+		URL:         "/api/workorders/" + a.WorkOrderID + "/attachments/" + a.ID + "/content",
+	}
+}
@@ -0,0 +1,105 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+const attachmentColumns = `a.id, a.workorder_id, a.file_name, a.content_type, a.size_bytes, a.checksum, a.storage_key, a.uploaded_by, a.created_at`
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, a Attachment) (Attachment, error) {
+	const query = `INSERT INTO workorder_attachments AS a (id, workorder_id, file_name, content_type, size_bytes, checksum, storage_key, uploaded_by, created_at)
+SELECT $2, id, $3, $4, $5, $6, $7, $8, $9 FROM workorders WHERE id = $1 AND tenant_id = $10
+RETURNING ` + attachmentColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	var created Attachment
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &created, query, a.WorkOrderID, a.ID, a.FileName, a.ContentType, a.Size, a.Checksum, a.StorageKey, a.UploadedBy, a.CreatedAt, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Attachment{}, sqlErrNotFound
+		}
+		return Attachment{}, fmt.Errorf("insert attachment: %w", err)
+	}
+	return created, nil
+}
+
+func (r *repository) Get(ctx context.Context, workOrderID, id string) (Attachment, error) {
+	const query = `SELECT ` + attachmentColumns + `
+FROM workorder_attachments a JOIN workorders w ON w.id = a.workorder_id
+WHERE a.id = $1 AND a.workorder_id = $2 AND w.tenant_id = $3`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	var a Attachment
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &a, query, id, workOrderID, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Attachment{}, sqlErrNotFound
+		}
+		return Attachment{}, fmt.Errorf("get attachment: %w", err)
+	}
+	return a, nil
+}
+
+func (r *repository) List(ctx context.Context, workOrderID string) ([]Attachment, error) {
+	const query = `SELECT ` + attachmentColumns + `
+FROM workorder_attachments a JOIN workorders w ON w.id = a.workorder_id
+WHERE a.workorder_id = $1 AND w.tenant_id = $2
+ORDER BY a.created_at, a.id`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Attachment{}
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &result, query, workOrderID, tenantID); err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+	return result, nil
+}
+
+func (r *repository) Delete(ctx context.Context, workOrderID, id string) error {
+	const query = `DELETE FROM workorder_attachments a USING workorders w
+WHERE a.id = $1 AND a.workorder_id = $2 AND w.id = a.workorder_id AND w.tenant_id = $3`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, id, workOrderID, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete attachment: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sqlErrNotFound
+	}
+	return nil
+}
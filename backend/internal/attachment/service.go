@@ -0,0 +1,324 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Service interface {
+	Upload(ctx context.Context, workOrderID string, upload Upload) (Attachment, error)
+	List(ctx context.Context, workOrderID string) ([]Attachment, error)
+	Get(ctx context.Context, workOrderID, id string) (Attachment, error)
+	Open(ctx context.Context, workOrderID, id string) (Attachment, Blob, error)
+	Delete(ctx context.Context, workOrderID, id string) error
+}
+
+type Repository interface {
+	Create(ctx context.Context, a Attachment) (Attachment, error)
+	Get(ctx context.Context, workOrderID, id string) (Attachment, error)
+	List(ctx context.Context, workOrderID string) ([]Attachment, error)
+	Delete(ctx context.Context, workOrderID, id string) error
+}
+
+type WorkOrders interface {
+	Get(ctx context.Context, id string) (workorder.WorkOrder, error)
+}
+
+type ProcessVariables interface {
+	SetProcessVariable(ctx context.Context, processInstanceID, name string, value any) error
+}
+
+type Upload struct {
+	FileName    string
+	ContentType string
+	Size        int64
+	Checksum    string
+	Content     io.Reader
+}
+
+type UploadError struct {
+	Message string
+}
+
+func (e *UploadError) Error() string { return e.Message }
+
+func IsInvalidUpload(err error) bool {
+	var target *UploadError
+	return errors.As(err, &target)
+}
+
+type LimitError struct {
+	Size int64
+	Max  int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("attachment of %d bytes exceeds the %d byte limit", e.Size, e.Max)
+}
+
+func IsTooLarge(err error) bool {
+	var target *LimitError
+	return errors.As(err, &target)
+}
+
+type ContentTypeError struct {
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("content type %s is not allowed", e.ContentType)
+}
+
+func IsUnsupportedType(err error) bool {
+	var target *ContentTypeError
+	return errors.As(err, &target)
+}
+
+type ChecksumError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected sha256 %s, got %s", e.Expected, e.Actual)
+}
+
+func IsChecksumMismatch(err error) bool {
+	var target *ChecksumError
+	return errors.As(err, &target)
+}
+
+type notFoundError struct{ id string }
+
+func (e notFoundError) Error() string { return fmt.Sprintf("attachment %s not found", e.id) }
+
+func (notFoundError) NotFound() {}
+
+func IsNotFound(err error) bool {
+	var target interface{ NotFound() }
+	return errors.As(err, &target)
+}
+
+var sqlErrNotFound = errors.New("attachment not found")
+
+type service struct {
+	repo       Repository
+	store      BlobStore
+	workorders WorkOrders
+	variables  ProcessVariables
+	cfg        config.AttachmentConfig
+}
+
+func NewService(repo Repository, store BlobStore, workorders WorkOrders, variables ProcessVariables, cfg config.AttachmentConfig) Service {
+	return &service{repo: repo, store: store, workorders: workorders, variables: variables, cfg: cfg}
+}
+
+func (s *service) Upload(ctx context.Context, workOrderID string, upload Upload) (Attachment, error) {
+	wo, err := s.workorders.Get(ctx, workOrderID)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	name := path.Base(strings.ReplaceAll(strings.TrimSpace(upload.FileName), "\\", "/"))
+	if name == "" || name == "." || name == "/" {
+		return Attachment{}, &UploadError{Message: "file name is required"}
+	}
+	if upload.Size <= 0 {
+		return Attachment{}, &UploadError{Message: "file is empty"}
+	}
+	if s.cfg.MaxSize > 0 && upload.Size > s.cfg.MaxSize {
+		return Attachment{}, &LimitError{Size: upload.Size, Max: s.cfg.MaxSize}
+	}
+	expected := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(upload.Checksum), "sha256:"))
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Attachment{}, fmt.Errorf("read upload: %w", err)
+	}
+	head = head[:n]
+
+	contentType := detectContentType(head, upload.ContentType)
+	if !s.allowed(contentType) {
+		return Attachment{}, &ContentTypeError{ContentType: contentType}
+	}
+
+	a := Attachment{
+		ID:          uuid.NewString(),
+		WorkOrderID: wo.ID,
+		FileName:    name,
+		ContentType: contentType,
+		Size:        upload.Size,
+		UploadedBy:  workorder.ActorFromContext(ctx),
+		CreatedAt:   time.Now().UTC(),
+	}
+	a.StorageKey = path.Join(wo.TenantID, wo.ID, a.ID)
+
+	hasher := sha256.New()
+	var written byteCounter
+	content := io.TeeReader(io.MultiReader(bytes.NewReader(head), upload.Content), io.MultiWriter(hasher, &written))
+	if err := s.store.Put(ctx, a.StorageKey, content, a.Size, a.ContentType); err != nil {
+		return Attachment{}, fmt.Errorf("store attachment: %w", err)
+	}
+
+	a.Checksum = hex.EncodeToString(hasher.Sum(nil))
+	switch {
+	case int64(written) != a.Size:
+		s.discard(ctx, a.StorageKey)
+		return Attachment{}, &UploadError{Message: fmt.Sprintf("received %d bytes, expected %d", written, a.Size)}
+	case expected != "" && expected != a.Checksum:
+		s.discard(ctx, a.StorageKey)
+		return Attachment{}, &ChecksumError{Expected: expected, Actual: a.Checksum}
+	}
+
+	created, err := s.repo.Create(ctx, a)
+	if err != nil {
+		s.discard(ctx, a.StorageKey)
+		if errors.Is(err, sqlErrNotFound) {
+			return Attachment{}, notFoundError{id: a.ID}
+		}
+		return Attachment{}, err
+	}
+
+	s.syncVariables(ctx, wo)
+	return created, nil
+}
+
+func (s *service) List(ctx context.Context, workOrderID string) ([]Attachment, error) {
+	if _, err := s.workorders.Get(ctx, workOrderID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, workOrderID)
+}
+
+func (s *service) Get(ctx context.Context, workOrderID, id string) (Attachment, error) {
+	a, err := s.repo.Get(ctx, workOrderID, id)
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Attachment{}, notFoundError{id: id}
+		}
+		return Attachment{}, err
+	}
+	return a, nil
+}
+
+func (s *service) Open(ctx context.Context, workOrderID, id string) (Attachment, Blob, error) {
+	a, err := s.Get(ctx, workOrderID, id)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+
+	blob, err := s.store.Open(ctx, a.StorageKey)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			return Attachment{}, nil, notFoundError{id: id}
+		}
+		return Attachment{}, nil, fmt.Errorf("open attachment: %w", err)
+	}
+	return a, blob, nil
+}
+
+func (s *service) Delete(ctx context.Context, workOrderID, id string) error {
+	wo, err := s.workorders.Get(ctx, workOrderID)
+	if err != nil {
+		return err
+	}
+	a, err := s.Get(ctx, workOrderID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, workOrderID, id); err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return notFoundError{id: id}
+		}
+		return err
+	}
+	s.discard(ctx, a.StorageKey)
+
+	s.syncVariables(ctx, wo)
+	return nil
+}
+
+func (s *service) syncVariables(ctx context.Context, wo workorder.WorkOrder) {
+	if s.variables == nil || wo.ProcessInstanceID == "" || wo.Status.Terminal() {
+		return
+	}
+
+	attachments, err := s.repo.List(ctx, wo.ID)
+	if err != nil {
+		log.Printf("attachment: sync process variable for workorder %s: %v", wo.ID, err)
+		return
+	}
+	refs := make([]Reference, 0, len(attachments))
+	for _, a := range attachments {
+		refs = append(refs, a.Reference())
+	}
+
+	if err := s.variables.SetProcessVariable(ctx, wo.ProcessInstanceID, ProcessVariable, refs); err != nil {
+		log.Printf("attachment: sync process variable for workorder %s: %v", wo.ID, err)
+	}
+}
+
+func (s *service) discard(ctx context.Context, key string) {
+	if err := s.store.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Printf("attachment: discard blob %s: %v", key, err)
+	}
+}
+
+func (s *service) allowed(contentType string) bool {
+	if len(s.cfg.AllowedTypes) == 0 {
+		return true
+	}
+	for _, allowed := range s.cfg.AllowedTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func detectContentType(head []byte, declared string) string {
+	detected := mediaType(http.DetectContentType(head))
+	if detected == "application/octet-stream" && declared != "" {
+		if mt := mediaType(declared); mt != "" {
+			return mt
+		}
+	}
+	return detected
+}
+
+func mediaType(value string) string {
+	mt, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(mt)
+}
+
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
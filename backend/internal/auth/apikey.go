@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type apiKey struct {
+	digest    [sha256.Size]byte
+	principal Principal
+}
+
+type APIKeyAuthenticator struct {
+	keys []apiKey
+}
+
+func NewAPIKeyAuthenticator(entries []config.APIKeyConfig) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{}
+	for _, entry := range entries {
+		if entry.Name == "" {
+			return nil, errors.New("api key name is required")
+		}
+		if entry.Key == "" {
+			return nil, fmt.Errorf("api key %s: key is required", entry.Name)
+		}
+		roles, err := ParseRoles(entry.Roles)
+		if err != nil {
+			return nil, fmt.Errorf("api key %s: %w", entry.Name, err)
+		}
+		if entry.Tenant == "" && len(entry.Tenants) == 0 {
+			return nil, fmt.Errorf("api key %s: tenant or tenants is required", entry.Name)
+		}
+		if entry.Tenant != "" && !tenant.Valid(entry.Tenant) {
+			return nil, fmt.Errorf("api key %s: invalid tenant %q", entry.Name, entry.Tenant)
+		}
+		for _, t := range entry.Tenants {
+			if t != AllTenants && !tenant.Valid(t) {
+				return nil, fmt.Errorf("api key %s: invalid tenant %q", entry.Name, t)
+			}
+		}
+		a.keys = append(a.keys, apiKey{
+			digest: sha256.Sum256([]byte(entry.Key)),
+			principal: Principal{
+				Subject: "sa:" + entry.Name,
+				Name:    entry.Name,
+				Kind:    KindServiceAccount,
+				Tenant:  entry.Tenant,
+				Tenants: entry.Tenants,
+				Roles:   roles,
+				Groups:  entry.Groups,
+			},
+		})
+	}
+	return a, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	presented := r.Header.Get(APIKeyHeader)
+	if presented == "" {
+		scheme, credentials, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "ApiKey") {
+			return Principal{}, ErrNoCredentials
+		}
+		presented = strings.TrimSpace(credentials)
+	}
+
+	digest := sha256.Sum256([]byte(presented))
+	matched := -1
+	for i, key := range a.keys {
+		if subtle.ConstantTimeCompare(digest[:], key.digest[:]) == 1 {
+			matched = i
+		}
+	}
+	if matched < 0 {
+		return Principal{}, &AuthenticationError{Scheme: "api key", Reason: "unknown key"}
+	}
+	return a.keys[matched].principal, nil
+}
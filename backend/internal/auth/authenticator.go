@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+)
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+type anonymous struct{}
+
+func Anonymous() Authenticator { return anonymous{} }
+
+func (anonymous) Authenticate(*http.Request) (Principal, error) {
+	return Principal{Subject: "anonymous", Kind: KindAnonymous, Tenants: []string{AllTenants}, Roles: []Role{RoleAdmin}}, nil
+}
+
+func New(cfg config.AuthConfig) (Authenticator, error) {
+	if !cfg.Enabled {
+		return Anonymous(), nil
+	}
+
+	var chain Chain
+	if len(cfg.APIKeys) > 0 {
+		keys, err := NewAPIKeyAuthenticator(cfg.APIKeys)
+		if err != nil {
+			return nil, fmt.Errorf("auth: api keys: %w", err)
+		}
+		chain = append(chain, keys)
+	}
+	if cfg.JWT.JWKSURL != "" || cfg.JWT.JWKSFile != "" {
+		jwt, err := NewJWTAuthenticator(cfg.JWT)
+		if err != nil {
+			return nil, fmt.Errorf("auth: jwt: %w", err)
+		}
+		chain = append(chain, jwt)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("auth: enabled but neither jwt nor api keys are configured")
+	}
+	return chain, nil
+}
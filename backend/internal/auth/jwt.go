@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type JWTAuthenticator struct {
+	keys        *KeySet
+	issuer      string
+	audience    string
+	rolesClaim  string
+	tenantClaim string
+	groupsClaim string
+	leeway      time.Duration
+	now         func() time.Time
+}
+
+func NewJWTAuthenticator(cfg config.JWTConfig) (*JWTAuthenticator, error) {
+	keys, err := NewKeySet(cfg.JWKSURL, cfg.JWKSFile, cfg.RefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+	rolesClaim := cfg.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+	return &JWTAuthenticator{
+		keys:        keys,
+		issuer:      cfg.Issuer,
+		audience:    cfg.Audience,
+		rolesClaim:  rolesClaim,
+		tenantClaim: cfg.TenantClaim,
+		groupsClaim: cfg.GroupsClaim,
+		leeway:      cfg.Leeway,
+		now:         time.Now,
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	claims, err := a.verify(r, strings.TrimSpace(token))
+	if err != nil {
+		return Principal{}, &AuthenticationError{Scheme: "bearer", Reason: err.Error()}
+	}
+
+	principal := Principal{Kind: KindUser, Roles: []Role{}}
+	principal.Subject, _ = claims["sub"].(string)
+	if principal.Subject == "" {
+		return Principal{}, &AuthenticationError{Scheme: "bearer", Reason: "token has no subject"}
+	}
+	for _, claim := range []string{"preferred_username", "name", "email"} {
+		if name, _ := claims[claim].(string); name != "" {
+			principal.Name = name
+			break
+		}
+	}
+	principal.Tenant = tenant.DefaultID
+	if a.tenantClaim != "" {
+		id, _ := lookupClaim(claims, a.tenantClaim).(string)
+		if !tenant.Valid(id) {
+			return Principal{}, &AuthenticationError{Scheme: "bearer", Reason: fmt.Sprintf("token has no valid %s claim", a.tenantClaim)}
+		}
+		principal.Tenant = id
+	}
+	if a.groupsClaim != "" {
+		principal.Groups = stringList(lookupClaim(claims, a.groupsClaim))
+	}
+	for _, raw := range stringList(lookupClaim(claims, a.rolesClaim)) {
+		role := Role(raw)
+		if _, ok := knownRoles[role]; ok {
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+	return principal, nil
+}
+
+func (a *JWTAuthenticator) verify(r *http.Request, token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+
+	key, err := a.keys.Key(r.Context(), header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) error {
+	now := a.now()
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(exp.Add(a.leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(a.leeway).Before(nbf) {
+		return errors.New("token not yet valid")
+	}
+	if a.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if a.audience != "" {
+		found := false
+		for _, aud := range stringList(claims["aud"]) {
+			if aud == a.audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("token audience does not match")
+		}
+	}
+	return nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("algorithm %s requires an RSA key", alg)
+		}
+		var err error
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(rsaKey, hash, digest, signature, nil)
+		}
+		if err != nil {
+			return errors.New("invalid signature")
+		}
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("algorithm %s requires an EC key", alg)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+	}
+	return nil
+}
+
+func decodeSegment(segment string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+func lookupClaim(claims map[string]any, path string) any {
+	var current any = claims
+	for _, segment := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = obj[segment]
+	}
+	return current
+}
+
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
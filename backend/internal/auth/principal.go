@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type Role string
+
+const (
+	RoleAdmin        Role = "admin"
+	RoleFlowDesigner Role = "flow-designer"
+	RoleOperator     Role = "operator"
+	RoleViewer       Role = "viewer"
+)
+
+var knownRoles = map[Role]struct{}{
+	RoleAdmin:        {},
+	RoleFlowDesigner: {},
+	RoleOperator:     {},
+	RoleViewer:       {},
+}
+
+var impliedRoles = map[Role][]Role{
+	RoleAdmin:        {RoleFlowDesigner, RoleOperator, RoleViewer},
+	RoleFlowDesigner: {RoleViewer},
+	RoleOperator:     {RoleViewer},
+}
+
+type PrincipalKind string
+
+const (
+	KindUser           PrincipalKind = "user"
+	KindServiceAccount PrincipalKind = "service-account"
+	KindAnonymous      PrincipalKind = "anonymous"
+)
+
+type Principal struct {
+	Subject string        `json:"subject"`
+	Name    string        `json:"name,omitempty"`
+	Kind    PrincipalKind `json:"kind"`
+	Tenant  string        `json:"tenant,omitempty"`
+	Tenants []string      `json:"tenants,omitempty"`
+	Roles   []Role        `json:"roles"`
+	Groups  []string      `json:"groups,omitempty"`
+}
+
+const AllTenants = "*"
+
+func (p Principal) MultiTenant() bool {
+	for _, t := range p.Tenants {
+		if t == AllTenants {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Principal) CanAccessTenant(id string) bool {
+	if id == p.Tenant || p.MultiTenant() {
+		return true
+	}
+	for _, t := range p.Tenants {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Principal) HasRole(role Role) bool {
+	for _, granted := range p.Roles {
+		if granted == role {
+			return true
+		}
+		for _, implied := range impliedRoles[granted] {
+			if implied == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p Principal) HasAnyRole(roles ...Role) bool {
+	for _, role := range roles {
+		if p.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+var ErrNoCredentials = errors.New("no credentials provided")
+
+type AuthenticationError struct {
+	Scheme string
+	Reason string
+}
+
+func (e *AuthenticationError) Error() string {
+	return fmt.Sprintf("%s authentication failed: %s", e.Scheme, e.Reason)
+}
+
+func IsUnauthenticated(err error) bool {
+	var target *AuthenticationError
+	return errors.Is(err, ErrNoCredentials) || errors.As(err, &target)
+}
+
+func ParseRoles(raw []string) ([]Role, error) {
+	roles := make([]Role, 0, len(raw))
+	for _, r := range raw {
+		role := Role(r)
+		if _, ok := knownRoles[role]; !ok {
+			return nil, fmt.Errorf("unknown role %q", r)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
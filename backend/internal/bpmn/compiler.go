@@ -0,0 +1,196 @@
+package bpmn
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	nsBPMN    = "http://www.omg.org/spec/BPMN/20100524/MODEL"
+	nsBPMNDI  = "http://www.omg.org/spec/BPMN/20100524/DI"
+	nsDC      = "http://www.omg.org/spec/DD/20100524/DC"
+	nsDI      = "http://www.omg.org/spec/DD/20100524/DI"
+	nsCamunda = "http://camunda.org/schema/1.0/bpmn"
+	nsXSI     = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+type size struct {
+	width  float64
+	height float64
+}
+
+var shapeSizes = map[NodeKind]size{
+	KindStartEvent:       {36, 36},
+	KindEndEvent:         {36, 36},
+	KindUserTask:         {100, 80},
+	KindServiceTask:      {100, 80},
+	KindExclusiveGateway: {50, 50},
+	KindParallelGateway:  {50, 50},
+}
+
+type Process struct {
+	Key        string
+	Name       string
+	Definition map[string]any
+}
+
+func ProcessKey(flowID string) string {
+	return "pflow_" + sanitizeID(flowID)
+}
+
+func ElementID(nodeID string) string {
+	if isNCName(nodeID) {
+		return nodeID
+	}
+	return "node_" + sanitizeID(nodeID)
+}
+
+func Compile(p Process) ([]byte, error) {
+	graph, problems := Analyze(p.Definition)
+	if len(problems) > 0 {
+		return nil, &CompileError{Problems: problems}
+	}
+
+	doc := buildDefinitions(p, graph)
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal bpmn: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func buildDefinitions(p Process, g Graph) definitions {
+	process := process{
+		ID:           p.Key,
+		Name:         p.Name,
+		IsExecutable: true,
+	}
+	plane := bpmnPlane{
+		ID:          "BPMNPlane_" + p.Key,
+		BPMNElement: p.Key,
+	}
+
+	for _, n := range g.Nodes {
+		id := ElementID(n.ID)
+		base := flowNode{ID: id, Name: n.Label}
+		for _, e := range g.Incoming(n.ID) {
+			base.Incoming = append(base.Incoming, flowID(e))
+		}
+		for _, e := range g.Outgoing(n.ID) {
+			base.Outgoing = append(base.Outgoing, flowID(e))
+		}
+
+		switch n.Kind {
+		case KindStartEvent:
+			process.Elements = append(process.Elements, startEvent{flowNode: base})
+		case KindEndEvent:
+			process.Elements = append(process.Elements, endEvent{flowNode: base})
+		case KindUserTask:
+			process.Elements = append(process.Elements, userTask{
+				flowNode:        base,
+				Assignee:        n.String("assignee"),
+				CandidateUsers:  n.String("candidateUsers"),
+				CandidateGroups: n.String("candidateGroups"),
+				FormKey:         n.String("formKey"),
+			})
+		case KindServiceTask:
+			process.Elements = append(process.Elements, serviceTask{
+				flowNode: base,
+				Type:     "external",
+				Topic:    n.String("topic"),
+			})
+		case KindExclusiveGateway:
+			process.Elements = append(process.Elements, exclusiveGateway{flowNode: base})
+		case KindParallelGateway:
+			process.Elements = append(process.Elements, parallelGateway{flowNode: base})
+		}
+
+		sz := shapeSizes[n.Kind]
+		plane.Shapes = append(plane.Shapes, bpmnShape{
+			ID:          id + "_di",
+			BPMNElement: id,
+			Bounds:      bounds{X: n.Position.X, Y: n.Position.Y, Width: sz.width, Height: sz.height},
+		})
+	}
+
+	for _, e := range g.Edges {
+		source, _ := g.Node(e.Source)
+		target, _ := g.Node(e.Target)
+
+		flow := sequenceFlow{
+			ID:        flowID(e),
+			Name:      e.Label,
+			SourceRef: ElementID(e.Source),
+			TargetRef: ElementID(e.Target),
+		}
+		if e.Condition != "" {
+			flow.Condition = &conditionExpression{Type: "bpmn:tFormalExpression", Body: e.Condition}
+		}
+		process.Elements = append(process.Elements, flow)
+
+		plane.Edges = append(plane.Edges, bpmnEdge{
+			ID:          flow.ID + "_di",
+			BPMNElement: flow.ID,
+			Waypoints:   []waypoint{center(source), center(target)},
+		})
+	}
+
+	return definitions{
+		XMLNSBPMN:       nsBPMN,
+		XMLNSBPMNDI:     nsBPMNDI,
+		XMLNSDC:         nsDC,
+		XMLNSDI:         nsDI,
+		XMLNSCamunda:    nsCamunda,
+		XMLNSXSI:        nsXSI,
+		ID:              "Definitions_" + p.Key,
+		TargetNamespace: "http://pflow.io/bpmn",
+		Exporter:        "pflow",
+		Process:         process,
+		Diagram: bpmnDiagram{
+			ID:    "BPMNDiagram_" + p.Key,
+			Plane: plane,
+		},
+	}
+}
+
+func flowID(e Edge) string {
+	return "Flow_" + sanitizeID(e.ID)
+}
+
+func center(n Node) waypoint {
+	sz := shapeSizes[n.Kind]
+	return waypoint{X: n.Position.X + sz.width/2, Y: n.Position.Y + sz.height/2}
+}
+
+func sanitizeID(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.') {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteRune('_')
+	}
+	return b.String()
+}
+
+func isNCName(id string) bool {
+	if id == "" {
+		return false
+	}
+	for i, r := range id {
+		if r >= unicode.MaxASCII {
+			return false
+		}
+		if i == 0 && !(unicode.IsLetter(r) || r == '_') {
+			return false
+		}
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
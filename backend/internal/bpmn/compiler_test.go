@@ -0,0 +1,281 @@
+package bpmn
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func node(id, typ string, data map[string]any) map[string]any {
+	n := map[string]any{"id": id, "type": typ, "position": map[string]any{"x": 0.0, "y": 0.0}}
+	if data != nil {
+		n["data"] = data
+	}
+	return n
+}
+
+func edge(id, source, target string) map[string]any {
+	return map[string]any{"id": id, "source": source, "target": target}
+}
+
+func conditional(id, source, target, condition string) map[string]any {
+	e := edge(id, source, target)
+	e["data"] = map[string]any{"condition": condition}
+	return e
+}
+
+func definition(nodes []any, edges []any) map[string]any {
+	return map[string]any{"nodes": nodes, "edges": edges}
+}
+
+func linear() map[string]any {
+	return definition(
+		[]any{node("start", "input", nil), node("review", "task", map[string]any{"label": "Review"}), node("end", "output", nil)},
+		[]any{edge("e1", "start", "review"), edge("e2", "review", "end")},
+	)
+}
+
+func TestCompile(t *testing.T) {
+	out, err := Compile(Process{Key: ProcessKey("flow-1"), Name: "Flow", Definition: linear()})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	var doc struct {
+		Process struct {
+			ID string `xml:"id,attr"`
+		} `xml:"process"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unmarshal compiled xml: %v", err)
+	}
+	if doc.Process.ID != "pflow_flow-1" {
+		t.Errorf("process id = %q, want pflow_flow-1", doc.Process.ID)
+	}
+
+	for _, want := range []string{`id="start"`, `id="review"`, `id="end"`, `id="Flow_e1"`, `sourceRef="start"`, `targetRef="end"`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("compiled xml is missing %s", want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition map[string]any
+		codes      []string
+	}{
+		{
+			name:       "empty",
+			definition: definition([]any{}, []any{}),
+			codes:      []string{CodeEmptyDefinition},
+		},
+		{
+			name:       "nodes not an array",
+			definition: map[string]any{"nodes": "start"},
+			codes:      []string{CodeMalformedDefinition},
+		},
+		{
+			name: "missing id",
+			definition: definition(
+				[]any{node("", "input", nil)},
+				nil,
+			),
+			codes: []string{CodeMissingID},
+		},
+		{
+			name: "unsupported node type",
+			definition: definition(
+				[]any{node("start", "input", nil), node("timer", "timerEvent", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "timer"), edge("e2", "timer", "end")},
+			),
+			codes: []string{CodeUnsupportedNodeType},
+		},
+		{
+			name: "missing start and end",
+			definition: definition(
+				[]any{node("a", "task", nil), node("b", "task", nil)},
+				[]any{edge("e1", "a", "b"), edge("e2", "b", "a")},
+			),
+			codes: []string{CodeMissingStartEvent, CodeMissingEndEvent, CodeCycleWithoutGateway},
+		},
+		{
+			name: "dangling edge",
+			definition: definition(
+				[]any{node("start", "input", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "end"), edge("e2", "start", "ghost")},
+			),
+			codes: []string{CodeDanglingEdge},
+		},
+		{
+			name: "self loop",
+			definition: definition(
+				[]any{node("start", "input", nil), node("gw", "gateway", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "gw"), conditional("e2", "gw", "gw", "${retry}"), edge("e3", "gw", "end")},
+			),
+			codes: []string{CodeSelfLoop},
+		},
+		{
+			name: "service task without topic",
+			definition: definition(
+				[]any{node("start", "input", nil), node("call", "serviceTask", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "call"), edge("e2", "call", "end")},
+			),
+			codes: []string{CodeMissingTopic},
+		},
+		{
+			name: "gateway without conditions",
+			definition: definition(
+				[]any{node("start", "input", nil), node("gw", "gateway", nil), node("a", "output", nil), node("b", "output", nil)},
+				[]any{edge("e1", "start", "gw"), edge("e2", "gw", "a"), edge("e3", "gw", "b")},
+			),
+			codes: []string{CodeMissingCondition},
+		},
+		{
+			name: "dead end and unreachable",
+			definition: definition(
+				[]any{node("start", "input", nil), node("stuck", "task", nil), node("orphan", "task", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "stuck"), edge("e2", "orphan", "end")},
+			),
+			codes: []string{CodeDeadEnd, CodeUnreachableNode, CodeUnreachableNode},
+		},
+		{
+			name: "edge into start and out of end",
+			definition: definition(
+				[]any{node("start", "input", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "end"), edge("e2", "end", "start")},
+			),
+			codes: []string{CodeInvalidConnection, CodeInvalidConnection, CodeCycleWithoutGateway},
+		},
+		{
+			name: "cycle without gateway",
+			definition: definition(
+				[]any{node("start", "input", nil), node("a", "task", nil), node("b", "task", nil), node("gw", "gateway", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "a"), edge("e2", "a", "b"), edge("e3", "b", "a"), edge("e4", "start", "gw"), conditional("e5", "gw", "end", "${done}"), conditional("e6", "gw", "a", "${again}")},
+			),
+			codes: []string{CodeCycleWithoutGateway},
+		},
+		{
+			name: "duplicate node id",
+			definition: definition(
+				[]any{node("start", "input", nil), node("task", "task", nil), node("task", "task", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "task"), edge("e2", "task", "end")},
+			),
+			codes: []string{CodeDuplicateID},
+		},
+		{
+			name: "duplicate edge id",
+			definition: definition(
+				[]any{node("start", "input", nil), node("end", "output", nil)},
+				[]any{edge("e1", "start", "end"), edge("e1", "start", "end")},
+			),
+			codes: []string{CodeDuplicateID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(Process{Key: "pflow_test", Definition: tt.definition})
+			compileErr, ok := AsCompileError(err)
+			if !ok {
+				t.Fatalf("Compile error = %v, want *CompileError", err)
+			}
+			assertCodes(t, compileErr.Problems, tt.codes)
+		})
+	}
+}
+
+func TestCompileAllowsCycleThroughGateway(t *testing.T) {
+	def := definition(
+		[]any{node("start", "input", nil), node("work", "task", nil), node("check", "gateway", nil), node("end", "output", nil)},
+		[]any{edge("e1", "start", "work"), edge("e2", "work", "check"), conditional("e3", "check", "work", "${!approved}"), conditional("e4", "check", "end", "${approved}")},
+	)
+	if _, err := Compile(Process{Key: "pflow_test", Definition: def}); err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+}
+
+func TestElementID(t *testing.T) {
+	tests := []struct {
+		nodeID string
+		want   string
+	}{
+		{"review", "review"},
+		{"_hidden", "_hidden"},
+		{"step.1-a", "step.1-a"},
+		{"1st", "node_1st"},
+		{"approve step", "node_approve_step"},
+		{"审批", "node___"},
+		{"", "node_"},
+	}
+
+	for _, tt := range tests {
+		if got := ElementID(tt.nodeID); got != tt.want {
+			t.Errorf("ElementID(%q) = %q, want %q", tt.nodeID, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRejectsIDCollisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		nodes  []any
+		edges  []any
+		nodeID string
+		edgeID string
+	}{
+		{
+			name:   "sanitized node ids",
+			nodes:  []any{node("start", "input", nil), node("approve step", "task", nil), node("approve?step", "task", nil), node("end", "output", nil)},
+			edges:  []any{edge("e1", "start", "approve step"), edge("e2", "approve step", "approve?step"), edge("e3", "approve?step", "end")},
+			nodeID: "approve?step",
+		},
+		{
+			name:   "prefixed node id",
+			nodes:  []any{node("start", "input", nil), node("1a", "task", nil), node("node_1a", "task", nil), node("end", "output", nil)},
+			edges:  []any{edge("e1", "start", "1a"), edge("e2", "1a", "node_1a"), edge("e3", "node_1a", "end")},
+			nodeID: "node_1a",
+		},
+		{
+			name:   "node and diagram shape",
+			nodes:  []any{node("start", "input", nil), node("end", "output", nil), node("start_di", "task", nil)},
+			edges:  []any{edge("e1", "start", "start_di"), edge("e2", "start_di", "end")},
+			nodeID: "start_di",
+		},
+		{
+			name:   "node and sequence flow",
+			nodes:  []any{node("start", "input", nil), node("Flow_e2", "task", nil), node("end", "output", nil)},
+			edges:  []any{edge("e1", "start", "Flow_e2"), edge("e2", "Flow_e2", "end")},
+			edgeID: "e2",
+		},
+		{
+			name:   "sanitized edge ids",
+			nodes:  []any{node("start", "input", nil), node("end", "output", nil)},
+			edges:  []any{edge("to end", "start", "end"), edge("to_end", "start", "end")},
+			edgeID: "to_end",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := Validate(definition(tt.nodes, tt.edges))
+			assertCodes(t, problems, []string{CodeIDCollision})
+			if problems[0].NodeID != tt.nodeID || problems[0].EdgeID != tt.edgeID {
+				t.Errorf("problem on node %q edge %q, want node %q edge %q", problems[0].NodeID, problems[0].EdgeID, tt.nodeID, tt.edgeID)
+			}
+		})
+	}
+}
+
+func assertCodes(t *testing.T, problems []Problem, want []string) {
+	t.Helper()
+
+	got := make([]string, len(problems))
+	for i, p := range problems {
+		got[i] = p.Code
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("problem codes = %v, want %v (%v)", got, want, problems)
+	}
+}
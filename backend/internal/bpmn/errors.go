@@ -0,0 +1,61 @@
+package bpmn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	CodeMalformedDefinition = "malformed_definition"
+	CodeMissingID           = "missing_id"
+	CodeDuplicateID         = "duplicate_id"
+	CodeIDCollision         = "id_collision"
+	CodeUnsupportedNodeType = "unsupported_node_type"
+	CodeMissingStartEvent   = "missing_start_event"
+	CodeMissingEndEvent     = "missing_end_event"
+	CodeDanglingEdge        = "dangling_edge"
+	CodeMissingTopic        = "missing_topic"
+	CodeEmptyDefinition     = "empty_definition"
+	CodeSelfLoop            = "self_loop"
+	CodeInvalidConnection   = "invalid_connection"
+	CodeMissingCondition    = "missing_condition"
+	CodeDeadEnd             = "dead_end"
+	CodeUnreachableNode     = "unreachable_node"
+	CodeCycleWithoutGateway = "cycle_without_gateway"
+	CodeInvalidForm         = "invalid_form"
+)
+
+type Problem struct {
+	Code    string `json:"code"`
+	NodeID  string `json:"nodeId,omitempty"`
+	EdgeID  string `json:"edgeId,omitempty"`
+	Message string `json:"message"`
+}
+
+type CompileError struct {
+	Problems []Problem
+}
+
+func (e *CompileError) Error() string {
+	messages := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		switch {
+		case p.NodeID != "":
+			messages = append(messages, fmt.Sprintf("node %s: %s", p.NodeID, p.Message))
+		case p.EdgeID != "":
+			messages = append(messages, fmt.Sprintf("edge %s: %s", p.EdgeID, p.Message))
+		default:
+			messages = append(messages, p.Message)
+		}
+	}
+	return "invalid flow definition: " + strings.Join(messages, "; ")
+}
+
+func AsCompileError(err error) (*CompileError, bool) {
+	var target *CompileError
+	if errors.As(err, &target) {
+		return target, true
+	}
+	return nil, false
+}
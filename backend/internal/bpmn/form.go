@@ -0,0 +1,199 @@
+package bpmn
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FieldType string
+
+const (
+	FieldString  FieldType = "string"
+	FieldText    FieldType = "text"
+	FieldNumber  FieldType = "number"
+	FieldInteger FieldType = "integer"
+	FieldBoolean FieldType = "boolean"
+	FieldDate    FieldType = "date"
+	FieldEnum    FieldType = "enum"
+)
+
+var fieldTypes = map[FieldType]bool{
+	FieldString:  true,
+	FieldText:    true,
+	FieldNumber:  true,
+	FieldInteger: true,
+	FieldBoolean: true,
+	FieldDate:    true,
+	FieldEnum:    true,
+}
+
+type FormField struct {
+	Name      string    `json:"name"`
+	Label     string    `json:"label,omitempty"`
+	Type      FieldType `json:"type"`
+	Required  bool      `json:"required,omitempty"`
+	Options   []string  `json:"options,omitempty"`
+	Min       *float64  `json:"min,omitempty"`
+	Max       *float64  `json:"max,omitempty"`
+	MinLength *int      `json:"minLength,omitempty"`
+	MaxLength *int      `json:"maxLength,omitempty"`
+	Pattern   string    `json:"pattern,omitempty"`
+}
+
+type Form struct {
+	Fields []FormField `json:"fields"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (n Node) Form() (Form, bool, error) {
+	if n.Data == nil || n.Data["form"] == nil {
+		return Form{}, false, nil
+	}
+
+	raw, err := json.Marshal(n.Data["form"])
+	if err != nil {
+		return Form{}, true, fmt.Errorf("data.form: %w", err)
+	}
+	var form Form
+	if err := json.Unmarshal(raw, &form); err != nil {
+		return Form{}, true, fmt.Errorf("data.form must be an object with a fields array")
+	}
+
+	seen := make(map[string]bool, len(form.Fields))
+	for i, field := range form.Fields {
+		switch {
+		case field.Name == "":
+			return Form{}, true, fmt.Errorf("data.form.fields[%d] has no name", i)
+		case seen[field.Name]:
+			return Form{}, true, fmt.Errorf("form field %q is declared more than once", field.Name)
+		case !fieldTypes[field.Type]:
+			return Form{}, true, fmt.Errorf("form field %q has unsupported type %q", field.Name, field.Type)
+		case field.Type == FieldEnum && len(field.Options) == 0:
+			return Form{}, true, fmt.Errorf("form field %q requires options", field.Name)
+		}
+		if field.Pattern != "" {
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				return Form{}, true, fmt.Errorf("form field %q has invalid pattern: %v", field.Name, err)
+			}
+		}
+		seen[field.Name] = true
+	}
+	return form, true, nil
+}
+
+func (f Form) Validate(values map[string]any) (map[string]any, []FieldError) {
+	var (
+		result   = make(map[string]any, len(values))
+		problems []FieldError
+		declared = make(map[string]bool, len(f.Fields))
+	)
+
+	for _, field := range f.Fields {
+		declared[field.Name] = true
+
+		value, ok := values[field.Name]
+		if !ok || value == nil || value == "" {
+			if field.Required {
+				problems = append(problems, FieldError{Field: field.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		normalized, message := field.check(value)
+		if message != "" {
+			problems = append(problems, FieldError{Field: field.Name, Message: message})
+			continue
+		}
+		result[field.Name] = normalized
+	}
+
+	var unknown []string
+	for name := range values {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, FieldError{Field: name, Message: "is not a field of this form"})
+	}
+
+	return result, problems
+}
+
+func (field FormField) check(value any) (any, string) {
+	switch field.Type {
+	case FieldString, FieldText:
+		s, ok := value.(string)
+		if !ok {
+			return nil, "must be a string"
+		}
+		length := len([]rune(s))
+		if field.MinLength != nil && length < *field.MinLength {
+			return nil, fmt.Sprintf("must be at least %d characters", *field.MinLength)
+		}
+		if field.MaxLength != nil && length > *field.MaxLength {
+			return nil, fmt.Sprintf("must be at most %d characters", *field.MaxLength)
+		}
+		if field.Pattern != "" && !regexp.MustCompile(field.Pattern).MatchString(s) {
+			return nil, fmt.Sprintf("must match %s", field.Pattern)
+		}
+		return s, ""
+	case FieldNumber, FieldInteger:
+		n, ok := value.(float64)
+		if !ok {
+			return nil, "must be a number"
+		}
+		if field.Type == FieldInteger && n != math.Trunc(n) {
+			return nil, "must be an integer"
+		}
+		if field.Min != nil && n < *field.Min {
+			return nil, fmt.Sprintf("must be at least %v", *field.Min)
+		}
+		if field.Max != nil && n > *field.Max {
+			return nil, fmt.Sprintf("must be at most %v", *field.Max)
+		}
+		if field.Type == FieldInteger {
+			return int64(n), ""
+		}
+		return n, ""
+	case FieldBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, "must be a boolean"
+		}
+		return b, ""
+	case FieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, "must be a date string"
+		}
+		for _, layout := range []string{time.DateOnly, time.RFC3339} {
+			if _, err := time.Parse(layout, s); err == nil {
+				return s, ""
+			}
+		}
+		return nil, "must be a date (YYYY-MM-DD) or RFC 3339 timestamp"
+	case FieldEnum:
+		s, ok := value.(string)
+		if !ok {
+			return nil, "must be a string"
+		}
+		for _, option := range field.Options {
+			if s == option {
+				return s, ""
+			}
+		}
+		return nil, "must be one of " + strings.Join(field.Options, ", ")
+	}
+	return nil, fmt.Sprintf("has unsupported type %q", field.Type)
+}
@@ -0,0 +1,213 @@
+package bpmn
+
+import (
+	"fmt"
+	"strings"
+)
+
+type NodeKind string
+
+const (
+	KindStartEvent       NodeKind = "startEvent"
+	KindEndEvent         NodeKind = "endEvent"
+	KindUserTask         NodeKind = "userTask"
+	KindServiceTask      NodeKind = "serviceTask"
+	KindExclusiveGateway NodeKind = "exclusiveGateway"
+	KindParallelGateway  NodeKind = "parallelGateway"
+)
+
+var nodeKinds = map[string]NodeKind{
+	"input":            KindStartEvent,
+	"start":            KindStartEvent,
+	"startEvent":       KindStartEvent,
+	"output":           KindEndEvent,
+	"end":              KindEndEvent,
+	"endEvent":         KindEndEvent,
+	"":                 KindUserTask,
+	"default":          KindUserTask,
+	"task":             KindUserTask,
+	"userTask":         KindUserTask,
+	"serviceTask":      KindServiceTask,
+	"gateway":          KindExclusiveGateway,
+	"exclusiveGateway": KindExclusiveGateway,
+	"parallelGateway":  KindParallelGateway,
+}
+
+type Position struct {
+	X float64
+	Y float64
+}
+
+type Node struct {
+	ID       string
+	Type     string
+	Kind     NodeKind
+	Label    string
+	Position Position
+	Data     map[string]any
+}
+
+type Edge struct {
+	ID        string
+	Source    string
+	Target    string
+	Label     string
+	Condition string
+}
+
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+func (g Graph) Node(id string) (Node, bool) {
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+func (g Graph) Outgoing(id string) []Edge {
+	var result []Edge
+	for _, e := range g.Edges {
+		if e.Source == id {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (g Graph) Incoming(id string) []Edge {
+	var result []Edge
+	for _, e := range g.Edges {
+		if e.Target == id {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (n Node) String(key string) string {
+	if n.Data == nil {
+		return ""
+	}
+	return stringValue(n.Data[key])
+}
+
+func Parse(definition map[string]any) (Graph, []Problem) {
+	var (
+		graph    Graph
+		problems []Problem
+	)
+
+	rawNodes, ok := listValue(definition["nodes"])
+	if !ok {
+		problems = append(problems, Problem{Code: CodeMalformedDefinition, Message: "definition.nodes must be an array"})
+	}
+	for i, raw := range rawNodes {
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			problems = append(problems, Problem{Code: CodeMalformedDefinition, Message: fmt.Sprintf("definition.nodes[%d] must be an object", i)})
+			continue
+		}
+
+		node := Node{
+			ID:   stringValue(obj["id"]),
+			Type: stringValue(obj["type"]),
+		}
+		if node.ID == "" {
+			problems = append(problems, Problem{Code: CodeMissingID, Message: fmt.Sprintf("definition.nodes[%d] has no id", i)})
+			continue
+		}
+
+		kind, ok := nodeKinds[node.Type]
+		if !ok {
+			problems = append(problems, Problem{Code: CodeUnsupportedNodeType, NodeID: node.ID, Message: fmt.Sprintf("node type %q is not supported", node.Type)})
+		}
+		node.Kind = kind
+
+		if data, ok := obj["data"].(map[string]any); ok {
+			node.Data = data
+			node.Label = stringValue(data["label"])
+		}
+		if pos, ok := obj["position"].(map[string]any); ok {
+			node.Position = Position{X: floatValue(pos["x"]), Y: floatValue(pos["y"])}
+		}
+
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	rawEdges, ok := listValue(definition["edges"])
+	if !ok && definition["edges"] != nil {
+		problems = append(problems, Problem{Code: CodeMalformedDefinition, Message: "definition.edges must be an array"})
+	}
+	for i, raw := range rawEdges {
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			problems = append(problems, Problem{Code: CodeMalformedDefinition, Message: fmt.Sprintf("definition.edges[%d] must be an object", i)})
+			continue
+		}
+
+		edge := Edge{
+			ID:     stringValue(obj["id"]),
+			Source: stringValue(obj["source"]),
+			Target: stringValue(obj["target"]),
+			Label:  stringValue(obj["label"]),
+		}
+		if edge.ID == "" {
+			edge.ID = fmt.Sprintf("edge-%s-%s", edge.Source, edge.Target)
+		}
+		if data, ok := obj["data"].(map[string]any); ok {
+			edge.Condition = strings.TrimSpace(stringValue(data["condition"]))
+		}
+
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	return graph, problems
+}
+
+func listValue(v any) ([]any, bool) {
+	switch list := v.(type) {
+	case []any:
+		return list, true
+	case []map[string]any:
+		result := make([]any, len(list))
+		for i, item := range list {
+			result[i] = item
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
+func stringValue(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case fmt.Stringer:
+		return s.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+func floatValue(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,260 @@
+package bpmn
+
+import (
+	"fmt"
+	"strings"
+)
+
+func Validate(definition map[string]any) []Problem {
+	_, problems := Analyze(definition)
+	return problems
+}
+
+func Analyze(definition map[string]any) (Graph, []Problem) {
+	graph, problems := Parse(definition)
+	if len(graph.Nodes) == 0 && len(problems) == 0 {
+		return graph, []Problem{{Code: CodeEmptyDefinition, Message: "definition has no nodes"}}
+	}
+
+	problems = append(problems, checkIDs(graph)...)
+	problems = append(problems, checkEdges(graph)...)
+	problems = append(problems, checkNodes(graph)...)
+	problems = append(problems, checkReachability(graph)...)
+	problems = append(problems, checkCycles(graph)...)
+	return graph, problems
+}
+
+func checkIDs(g Graph) []Problem {
+	var (
+		problems []Problem
+		nodes    = make(map[string]bool)
+		edges    = make(map[string]bool)
+		elements = make(map[string]string)
+	)
+
+	claim := func(owner string, ids ...string) string {
+		for _, id := range ids {
+			if other, ok := elements[id]; ok {
+				return other
+			}
+		}
+		for _, id := range ids {
+			elements[id] = owner
+		}
+		return ""
+	}
+
+	for _, n := range g.Nodes {
+		if nodes[n.ID] {
+			problems = append(problems, Problem{Code: CodeDuplicateID, NodeID: n.ID, Message: "node id is used more than once"})
+			continue
+		}
+		nodes[n.ID] = true
+
+		elementID := ElementID(n.ID)
+		if other := claim("node "+n.ID, elementID, elementID+"_di"); other != "" {
+			problems = append(problems, Problem{Code: CodeIDCollision, NodeID: n.ID, Message: fmt.Sprintf("BPMN id %s collides with %s", elementID, other)})
+		}
+	}
+
+	for _, e := range g.Edges {
+		if edges[e.ID] {
+			problems = append(problems, Problem{Code: CodeDuplicateID, EdgeID: e.ID, Message: "edge id is used more than once"})
+			continue
+		}
+		edges[e.ID] = true
+
+		elementID := flowID(e)
+		if other := claim("edge "+e.ID, elementID, elementID+"_di"); other != "" {
+			problems = append(problems, Problem{Code: CodeIDCollision, EdgeID: e.ID, Message: fmt.Sprintf("BPMN id %s collides with %s", elementID, other)})
+		}
+	}
+
+	return problems
+}
+
+func checkEdges(g Graph) []Problem {
+	var problems []Problem
+	for _, e := range g.Edges {
+		if _, ok := g.Node(e.Source); !ok {
+			problems = append(problems, Problem{Code: CodeDanglingEdge, EdgeID: e.ID, Message: fmt.Sprintf("source node %q does not exist", e.Source)})
+		}
+		if _, ok := g.Node(e.Target); !ok {
+			problems = append(problems, Problem{Code: CodeDanglingEdge, EdgeID: e.ID, Message: fmt.Sprintf("target node %q does not exist", e.Target)})
+		}
+		if e.Source != "" && e.Source == e.Target {
+			problems = append(problems, Problem{Code: CodeSelfLoop, EdgeID: e.ID, Message: "edge connects a node to itself"})
+		}
+	}
+	return problems
+}
+
+func checkNodes(g Graph) []Problem {
+	var (
+		problems []Problem
+		starts   int
+		ends     int
+	)
+
+	for _, n := range g.Nodes {
+		incoming := g.Incoming(n.ID)
+		outgoing := g.Outgoing(n.ID)
+
+		switch n.Kind {
+		case KindStartEvent:
+			starts++
+			if len(incoming) > 0 {
+				problems = append(problems, Problem{Code: CodeInvalidConnection, NodeID: n.ID, Message: "start node cannot have incoming edges"})
+			}
+		case KindEndEvent:
+			ends++
+			if len(outgoing) > 0 {
+				problems = append(problems, Problem{Code: CodeInvalidConnection, NodeID: n.ID, Message: "end node cannot have outgoing edges"})
+			}
+		case KindServiceTask:
+			if n.String("topic") == "" {
+				problems = append(problems, Problem{Code: CodeMissingTopic, NodeID: n.ID, Message: "service task requires data.topic"})
+			}
+		case KindExclusiveGateway:
+			var unconditioned int
+			for _, e := range outgoing {
+				if e.Condition == "" {
+					unconditioned++
+				}
+			}
+			if len(outgoing) > 1 && unconditioned > 1 {
+				problems = append(problems, Problem{Code: CodeMissingCondition, NodeID: n.ID, Message: "exclusive gateway has more than one outgoing edge without data.condition"})
+			}
+		}
+
+		if _, ok, err := n.Form(); ok && err != nil {
+			problems = append(problems, Problem{Code: CodeInvalidForm, NodeID: n.ID, Message: err.Error()})
+		}
+
+		if n.Kind != KindEndEvent && n.Kind != "" && len(outgoing) == 0 {
+			problems = append(problems, Problem{Code: CodeDeadEnd, NodeID: n.ID, Message: "node has no outgoing edges"})
+		}
+	}
+
+	if starts == 0 && len(g.Nodes) > 0 {
+		problems = append(problems, Problem{Code: CodeMissingStartEvent, Message: "definition has no start node"})
+	}
+	if ends == 0 && len(g.Nodes) > 0 {
+		problems = append(problems, Problem{Code: CodeMissingEndEvent, Message: "definition has no end node"})
+	}
+
+	return problems
+}
+
+func checkReachability(g Graph) []Problem {
+	var queue []string
+	for _, n := range g.Nodes {
+		if n.Kind == KindStartEvent {
+			queue = append(queue, n.ID)
+		}
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+
+	reached := make(map[string]bool)
+	for _, id := range queue {
+		reached[id] = true
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, e := range g.Outgoing(id) {
+			if !reached[e.Target] {
+				reached[e.Target] = true
+				queue = append(queue, e.Target)
+			}
+		}
+	}
+
+	var problems []Problem
+	for _, n := range g.Nodes {
+		if !reached[n.ID] {
+			problems = append(problems, Problem{Code: CodeUnreachableNode, NodeID: n.ID, Message: "node cannot be reached from a start node"})
+		}
+	}
+	return problems
+}
+
+func checkCycles(g Graph) []Problem {
+	var problems []Problem
+	for _, component := range stronglyConnected(g) {
+		if len(component) == 1 {
+			continue
+		}
+
+		hasGateway := false
+		for _, id := range component {
+			if n, _ := g.Node(id); n.Kind == KindExclusiveGateway {
+				hasGateway = true
+				break
+			}
+		}
+		if !hasGateway {
+			problems = append(problems, Problem{
+				Code:    CodeCycleWithoutGateway,
+				NodeID:  component[0],
+				Message: fmt.Sprintf("cycle through %s has no exclusive gateway to exit it", strings.Join(component, ", ")),
+			})
+		}
+	}
+	return problems
+}
+
+func stronglyConnected(g Graph) [][]string {
+	var (
+		index      int
+		stack      []string
+		onStack    = make(map[string]bool)
+		indices    = make(map[string]int)
+		lowlinks   = make(map[string]int)
+		components [][]string
+		visit      func(id string)
+	)
+
+	visit = func(id string) {
+		indices[id] = index
+		lowlinks[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, e := range g.Outgoing(id) {
+			if _, ok := g.Node(e.Target); !ok {
+				continue
+			}
+			if _, seen := indices[e.Target]; !seen {
+				visit(e.Target)
+				lowlinks[id] = min(lowlinks[id], lowlinks[e.Target])
+			} else if onStack[e.Target] {
+				lowlinks[id] = min(lowlinks[id], indices[e.Target])
+			}
+		}
+
+		if lowlinks[id] == indices[id] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == id {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if _, seen := indices[n.ID]; !seen {
+			visit(n.ID)
+		}
+	}
+	return components
+}
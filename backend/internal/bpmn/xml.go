@@ -0,0 +1,118 @@
+package bpmn
+
+import "encoding/xml"
+
+type definitions struct {
+	XMLName         xml.Name    `xml:"bpmn:definitions"`
+	XMLNSBPMN       string      `xml:"xmlns:bpmn,attr"`
+	XMLNSBPMNDI     string      `xml:"xmlns:bpmndi,attr"`
+	XMLNSDC         string      `xml:"xmlns:dc,attr"`
+	XMLNSDI         string      `xml:"xmlns:di,attr"`
+	XMLNSCamunda    string      `xml:"xmlns:camunda,attr"`
+	XMLNSXSI        string      `xml:"xmlns:xsi,attr"`
+	ID              string      `xml:"id,attr"`
+	TargetNamespace string      `xml:"targetNamespace,attr"`
+	Exporter        string      `xml:"exporter,attr"`
+	Process         process     `xml:"bpmn:process"`
+	Diagram         bpmnDiagram `xml:"bpmndi:BPMNDiagram"`
+}
+
+type process struct {
+	ID           string `xml:"id,attr"`
+	Name         string `xml:"name,attr,omitempty"`
+	IsExecutable bool   `xml:"isExecutable,attr"`
+	Elements     []any
+}
+
+type flowNode struct {
+	ID       string   `xml:"id,attr"`
+	Name     string   `xml:"name,attr,omitempty"`
+	Incoming []string `xml:"bpmn:incoming"`
+	Outgoing []string `xml:"bpmn:outgoing"`
+}
+
+type startEvent struct {
+	XMLName xml.Name `xml:"bpmn:startEvent"`
+	flowNode
+}
+
+type endEvent struct {
+	XMLName xml.Name `xml:"bpmn:endEvent"`
+	flowNode
+}
+
+type userTask struct {
+	XMLName xml.Name `xml:"bpmn:userTask"`
+	flowNode
+	Assignee        string `xml:"camunda:assignee,attr,omitempty"`
+	CandidateUsers  string `xml:"camunda:candidateUsers,attr,omitempty"`
+	CandidateGroups string `xml:"camunda:candidateGroups,attr,omitempty"`
+	FormKey         string `xml:"camunda:formKey,attr,omitempty"`
+}
+
+type serviceTask struct {
+	XMLName xml.Name `xml:"bpmn:serviceTask"`
+	flowNode
+	Type  string `xml:"camunda:type,attr"`
+	Topic string `xml:"camunda:topic,attr"`
+}
+
+type exclusiveGateway struct {
+	XMLName xml.Name `xml:"bpmn:exclusiveGateway"`
+	flowNode
+}
+
+type parallelGateway struct {
+	XMLName xml.Name `xml:"bpmn:parallelGateway"`
+	flowNode
+}
+
+type sequenceFlow struct {
+	XMLName   xml.Name             `xml:"bpmn:sequenceFlow"`
+	ID        string               `xml:"id,attr"`
+	Name      string               `xml:"name,attr,omitempty"`
+	SourceRef string               `xml:"sourceRef,attr"`
+	TargetRef string               `xml:"targetRef,attr"`
+	Condition *conditionExpression `xml:"bpmn:conditionExpression,omitempty"`
+}
+
+type conditionExpression struct {
+	Type string `xml:"xsi:type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type bpmnDiagram struct {
+	ID    string    `xml:"id,attr"`
+	Plane bpmnPlane `xml:"bpmndi:BPMNPlane"`
+}
+
+type bpmnPlane struct {
+	ID          string      `xml:"id,attr"`
+	BPMNElement string      `xml:"bpmnElement,attr"`
+	Shapes      []bpmnShape `xml:"bpmndi:BPMNShape"`
+	Edges       []bpmnEdge  `xml:"bpmndi:BPMNEdge"`
+}
+
+type bpmnShape struct {
+	ID          string `xml:"id,attr"`
+	BPMNElement string `xml:"bpmnElement,attr"`
+	Bounds      bounds `xml:"dc:Bounds"`
+}
+
+type bounds struct {
+	X      float64 `xml:"x,attr"`
+	Y      float64 `xml:"y,attr"`
+	Width  float64 `xml:"width,attr"`
+	Height float64 `xml:"height,attr"`
+}
+
+type bpmnEdge struct {
+	ID          string     `xml:"id,attr"`
+	BPMNElement string     `xml:"bpmnElement,attr"`
+	Waypoints   []waypoint `xml:"di:waypoint"`
+}
+
+type waypoint struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+}
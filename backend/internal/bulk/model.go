@@ -0,0 +1,86 @@
+package bulk
+
+import (
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Action string
+
+const (
+	ActionCreate   Action = "create"
+	ActionRetry    Action = "retry"
+	ActionCancel   Action = "cancel"
+	ActionReassign Action = "reassign"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+)
+
+type ItemStatus string
+
+const (
+	ItemPending   ItemStatus = "pending"
+	ItemSucceeded ItemStatus = "succeeded"
+	ItemFailed    ItemStatus = "failed"
+)
+
+type Job struct {
+	ID         string     `json:"id" db:"id"`
+	TenantID   string     `json:"tenantId" db:"tenant_id"`
+	Action     Action     `json:"action" db:"action"`
+	Status     JobStatus  `json:"status" db:"status"`
+	Total      int        `json:"total" db:"total"`
+	Succeeded  int        `json:"succeeded" db:"succeeded"`
+	Failed     int        `json:"failed" db:"failed"`
+	Reason     string     `json:"reason,omitempty" db:"reason"`
+	Assignee   string     `json:"assignee,omitempty" db:"assignee"`
+	CreatedBy  string     `json:"createdBy" db:"created_by"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty" db:"finished_at"`
+	Items      []Item     `json:"items,omitempty" db:"-"`
+}
+
+type Item struct {
+	Index       int        `json:"index" db:"idx"`
+	WorkOrderID string     `json:"workOrderId,omitempty" db:"workorder_id"`
+	Status      ItemStatus `json:"status" db:"status"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+type Claim struct {
+	JobID       string `db:"job_id"`
+	Index       int    `db:"idx"`
+	WorkOrderID string `db:"workorder_id"`
+	Input       []byte `db:"input"`
+	TenantID    string `db:"tenant_id"`
+	Action      Action `db:"action"`
+	Reason      string `db:"reason"`
+	Assignee    string `db:"assignee"`
+	CreatedBy   string `db:"created_by"`
+}
+
+type ActionFilter struct {
+	Statuses   []workorder.Status   `json:"status"`
+	FlowID     string               `json:"flowId"`
+	Assignee   string               `json:"assignee"`
+	Priorities []workorder.Priority `json:"priority"`
+	SLAStates  []workorder.SLAState `json:"slaState"`
+	Metadata   map[string]string    `json:"metadata"`
+}
+
+type ActionInput struct {
+	Action   Action
+	IDs      []string
+	Filter   *ActionFilter
+	Reason   string
+	Assignee string
+}
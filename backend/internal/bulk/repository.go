@@ -0,0 +1,138 @@
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+const jobColumns = `id, tenant_id, action, status, total, succeeded, failed, reason, assignee, created_by, created_at, updated_at, finished_at`
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateJob(ctx context.Context, job Job, workOrderIDs, inputs []string) (Job, error) {
+	const insertJob = `INSERT INTO bulk_jobs (id, tenant_id, action, status, total, reason, assignee, created_by, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$9)`
+	const insertItems = `INSERT INTO bulk_job_items (job_id, idx, workorder_id, input, status, updated_at)
+SELECT $1, item.idx - 1, NULLIF(item.workorder_id, ''), NULLIF(item.input, '')::jsonb, $4, $5
+FROM unnest($2::text[], $3::text[]) WITH ORDINALITY AS item(workorder_id, input, idx)`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Job{}, err
+	}
+
+	now := time.Now().UTC()
+	job.TenantID = tenantID
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	exec := persistence.ExecutorFromContext(ctx, r.db)
+	if _, err := exec.ExecContext(ctx, insertJob, job.ID, job.TenantID, job.Action, job.Status, job.Total, job.Reason, job.Assignee, job.CreatedBy, now); err != nil {
+		return Job{}, fmt.Errorf("insert bulk job: %w", err)
+	}
+	if _, err := exec.ExecContext(ctx, insertItems, job.ID, workOrderIDs, inputs, ItemPending, now); err != nil {
+		return Job{}, fmt.Errorf("insert bulk job items: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *repository) GetJob(ctx context.Context, id string) (Job, error) {
+	const query = `SELECT ` + jobColumns + ` FROM bulk_jobs WHERE id = $1 AND tenant_id = $2`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Job{}, err
+	}
+
+	var job Job
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &job, query, id, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Job{}, sqlErrNotFound
+		}
+		return Job{}, fmt.Errorf("get bulk job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *repository) ListItems(ctx context.Context, jobID string, status ItemStatus) ([]Item, error) {
+	const query = `SELECT i.idx, COALESCE(i.workorder_id, '') AS workorder_id, i.status, COALESCE(i.error, '') AS error, i.updated_at
+FROM bulk_job_items i JOIN bulk_jobs j ON j.id = i.job_id
+WHERE i.job_id = $1 AND j.tenant_id = $2 AND ($3 = '' OR i.status = $3)
+ORDER BY i.idx`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &items, query, jobID, tenantID, string(status)); err != nil {
+		return nil, fmt.Errorf("list bulk job items: %w", err)
+	}
+	return items, nil
+}
+
+func (r *repository) ClaimItems(ctx context.Context, staleBefore time.Time, limit int) ([]Claim, error) {
+	const query = `WITH claimed AS (
+    UPDATE bulk_job_items SET claimed_at = $1
+    WHERE (job_id, idx) IN (
+        SELECT i.job_id, i.idx FROM bulk_job_items i JOIN bulk_jobs j ON j.id = i.job_id
+        WHERE i.status = 'pending' AND (i.claimed_at IS NULL OR i.claimed_at <= $2)
+        ORDER BY j.created_at, i.idx
+        LIMIT $3
+        FOR UPDATE OF i SKIP LOCKED
+    )
+    RETURNING job_id, idx, workorder_id, input
+), started AS (
+    UPDATE bulk_jobs SET status = 'running', updated_at = $1
+    WHERE id IN (SELECT job_id FROM claimed) AND status = 'queued'
+)
+SELECT c.job_id, c.idx, COALESCE(c.workorder_id, '') AS workorder_id, c.input, j.tenant_id, j.action, j.reason, j.assignee, j.created_by
+FROM claimed c JOIN bulk_jobs j ON j.id = c.job_id
+ORDER BY j.created_at, c.idx`
+
+	var claims []Claim
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &claims, query, time.Now().UTC(), staleBefore, limit); err != nil {
+		return nil, fmt.Errorf("claim bulk job items: %w", err)
+	}
+	return claims, nil
+}
+
+func (r *repository) RecordItem(ctx context.Context, jobID string, item Item) error {
+	const query = `WITH item AS (
+    UPDATE bulk_job_items SET workorder_id = NULLIF($3, ''), status = $4, error = NULLIF($5, ''), updated_at = $6
+    WHERE job_id = $1 AND idx = $2 AND status = 'pending'
+    RETURNING status
+)
+UPDATE bulk_jobs SET
+    succeeded = succeeded + (SELECT count(*) FROM item WHERE status = 'succeeded'),
+    failed = failed + (SELECT count(*) FROM item WHERE status = 'failed'),
+    status = CASE WHEN succeeded + failed + (SELECT count(*) FROM item) >= total THEN 'completed' ELSE status END,
+    finished_at = CASE WHEN succeeded + failed + (SELECT count(*) FROM item) >= total THEN $6 ELSE finished_at END,
+    updated_at = $6
+WHERE id = $1 AND tenant_id = $7`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, jobID, item.Index, item.WorkOrderID, item.Status, item.Error, item.UpdatedAt, tenantID); err != nil {
+		return fmt.Errorf("record bulk job item: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Service interface {
+	CreateBatch(ctx context.Context, items []workorder.CreateInput) (Job, error)
+	Submit(ctx context.Context, input ActionInput) (Job, error)
+	Get(ctx context.Context, id string, itemStatus ItemStatus) (Job, error)
+}
+
+type Repository interface {
+	CreateJob(ctx context.Context, job Job, workOrderIDs, inputs []string) (Job, error)
+	GetJob(ctx context.Context, id string) (Job, error)
+	ListItems(ctx context.Context, jobID string, status ItemStatus) ([]Item, error)
+	ClaimItems(ctx context.Context, staleBefore time.Time, limit int) ([]Claim, error)
+	RecordItem(ctx context.Context, jobID string, item Item) error
+}
+
+type InputError struct {
+	Message string
+}
+
+func (e *InputError) Error() string { return e.Message }
+
+func IsInvalidInput(err error) bool {
+	var target *InputError
+	return errors.As(err, &target)
+}
+
+type notFoundError struct{ id string }
+
+func (e notFoundError) Error() string { return fmt.Sprintf("bulk job %s not found", e.id) }
+
+func (notFoundError) NotFound() {}
+
+func IsNotFound(err error) bool {
+	var target interface{ NotFound() }
+	return errors.As(err, &target)
+}
+
+type Runner struct {
+	repo       Repository
+	tx         persistence.Transactor
+	workorders workorder.Service
+	cfg        config.BulkConfig
+	wake       chan struct{}
+}
+
+func NewRunner(repo Repository, tx persistence.Transactor, workorders workorder.Service, cfg config.BulkConfig) *Runner {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	return &Runner{
+		repo:       repo,
+		tx:         tx,
+		workorders: workorders,
+		cfg:        cfg,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func (r *Runner) Run(ctx context.Context) {
+	claims := make(chan Claim)
+	var wg sync.WaitGroup
+	for i := 0; i < r.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range claims {
+				r.process(context.WithoutCancel(ctx), c)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(claims)
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		batch, err := r.repo.ClaimItems(ctx, time.Now().UTC().Add(-r.cfg.ClaimTimeout), r.cfg.Workers)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("bulk runner: %v", err)
+		}
+		for _, c := range batch {
+			select {
+			case claims <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(batch) == r.cfg.Workers {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+func (r *Runner) CreateBatch(ctx context.Context, items []workorder.CreateInput) (Job, error) {
+	if err := r.checkSize(len(items)); err != nil {
+		return Job{}, err
+	}
+
+	jobID := uuid.NewString()
+	inputs := make([]string, len(items))
+	for i, input := range items {
+		if input.IdempotencyKey == "" {
+			input.IdempotencyKey = fmt.Sprintf("bulk:%s:%d", jobID, i)
+		}
+		raw, err := json.Marshal(input)
+		if err != nil {
+			return Job{}, fmt.Errorf("encode item %d: %w", i, err)
+		}
+		inputs[i] = string(raw)
+	}
+
+	return r.start(ctx, Job{ID: jobID, Action: ActionCreate}, make([]string, len(items)), inputs)
+}
+
+func (r *Runner) Submit(ctx context.Context, input ActionInput) (Job, error) {
+	switch input.Action {
+	case ActionRetry, ActionCancel:
+	case ActionReassign:
+		if input.Assignee == "" {
+			return Job{}, &InputError{Message: "assignee is required for reassign"}
+		}
+	default:
+		return Job{}, &InputError{Message: fmt.Sprintf("unsupported action %q", input.Action)}
+	}
+
+	if len(input.IDs) > 0 && input.Filter != nil {
+		return Job{}, &InputError{Message: "specify either ids or filter, not both"}
+	}
+
+	ids := input.IDs
+	if input.Filter != nil {
+		var err error
+		if ids, err = r.resolve(ctx, *input.Filter); err != nil {
+			return Job{}, err
+		}
+	}
+	if err := r.checkSize(len(ids)); err != nil {
+		return Job{}, err
+	}
+
+	job := Job{ID: uuid.NewString(), Action: input.Action, Reason: input.Reason, Assignee: input.Assignee}
+	return r.start(ctx, job, ids, make([]string, len(ids)))
+}
+
+func (r *Runner) Get(ctx context.Context, id string, itemStatus ItemStatus) (Job, error) {
+	job, err := r.repo.GetJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Job{}, notFoundError{id: id}
+		}
+		return Job{}, err
+	}
+
+	if job.Items, err = r.repo.ListItems(ctx, id, itemStatus); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+func (r *Runner) checkSize(n int) error {
+	if n == 0 {
+		return &InputError{Message: "no items to process"}
+	}
+	if n > r.cfg.MaxItems {
+		return &InputError{Message: fmt.Sprintf("%d items exceed the limit of %d per job", n, r.cfg.MaxItems)}
+	}
+	return nil
+}
+
+func (r *Runner) resolve(ctx context.Context, filter ActionFilter) ([]string, error) {
+	query := workorder.ListFilter{
+		Statuses:   filter.Statuses,
+		FlowID:     filter.FlowID,
+		Assignee:   filter.Assignee,
+		Priorities: filter.Priorities,
+		SLAStates:  filter.SLAStates,
+		Metadata:   filter.Metadata,
+		Ascending:  true,
+		Limit:      workorder.MaxPageSize,
+	}
+
+	var ids []string
+	for {
+		page, err := r.workorders.List(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, wo := range page.Items {
+			ids = append(ids, wo.ID)
+		}
+		if len(ids) > r.cfg.MaxItems {
+			return nil, &InputError{Message: fmt.Sprintf("filter matches more than %d work orders", r.cfg.MaxItems)}
+		}
+		if page.NextCursor == "" {
+			return ids, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func (r *Runner) start(ctx context.Context, job Job, ids, inputs []string) (Job, error) {
+	job.Status = JobQueued
+	job.Total = len(ids)
+	job.CreatedBy = workorder.ActorFromContext(ctx)
+
+	err := r.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		job, err = r.repo.CreateJob(ctx, job, ids, inputs)
+		return err
+	})
+	if err != nil {
+		return Job{}, err
+	}
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (r *Runner) process(ctx context.Context, c Claim) {
+	ctx = workorder.WithActor(tenant.WithTenant(ctx, c.TenantID), c.CreatedBy)
+
+	item := Item{Index: c.Index, Status: ItemSucceeded}
+	id, err := r.execute(ctx, c)
+	item.WorkOrderID = id
+	item.UpdatedAt = time.Now().UTC()
+	if err != nil {
+		item.Status = ItemFailed
+		item.Error = err.Error()
+	}
+
+	if err := r.repo.RecordItem(ctx, c.JobID, item); err != nil {
+		log.Printf("bulk job %s item %d: %v", c.JobID, c.Index, err)
+	}
+}
+
+func (r *Runner) execute(ctx context.Context, c Claim) (string, error) {
+	switch c.Action {
+	case ActionCreate:
+		var input workorder.CreateInput
+		if err := json.Unmarshal(c.Input, &input); err != nil {
+			return "", fmt.Errorf("decode item input: %w", err)
+		}
+		wo, _, err := r.workorders.Create(ctx, input)
+		return wo.ID, err
+	case ActionRetry:
+		return c.WorkOrderID, r.workorders.Retry(ctx, c.WorkOrderID)
+	case ActionCancel:
+		_, err := r.workorders.Cancel(ctx, c.WorkOrderID, c.Reason)
+		return c.WorkOrderID, err
+	case ActionReassign:
+		_, err := r.workorders.Assign(ctx, c.WorkOrderID, c.Assignee, c.Reason)
+		return c.WorkOrderID, err
+	default:
+		return c.WorkOrderID, fmt.Errorf("unsupported action %q", c.Action)
+	}
+}
+
+func (r *Runner) withinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.tx == nil {
+		return fn(ctx)
+	}
+	return r.tx.WithinTransaction(ctx, fn)
+}
+
+var sqlErrNotFound = errors.New("bulk job not found")
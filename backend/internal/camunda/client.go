@@ -1,12 +1,13 @@
 package camunda
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/go-resty/resty/v2"
 
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
 )
@@ -30,34 +31,82 @@ func (c *Client) HTTP() *resty.Client {
 	return c.resty
 }
 
-func (c *Client) Deploy(ctx context.Context, f flow.Flow) error {
-	payload := map[string]any{
+type deploymentDTO struct {
+	ID                         string                          `json:"id"`
+	DeployedProcessDefinitions map[string]processDefinitionDTO `json:"deployedProcessDefinitions"`
+}
+
+type processDefinitionDTO struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+func (c *Client) Deploy(ctx context.Context, f flow.Flow, xml []byte) (flow.Deployment, error) {
+	key := bpmn.ProcessKey(f.ID)
+
+	form := map[string]string{
 		"deployment-name":     fmt.Sprintf("pflow-%s", f.ID),
-		"deploy-changed-only": true,
-		"resources": map[string]string{
-			fmt.Sprintf("%s.bpmn", f.ID): mustJSON(f.Definition),
-		},
+		"deployment-source":   "pflow",
+		"deploy-changed-only": "true",
+	}
+	if f.TenantID != "" {
+		form["tenant-id"] = f.TenantID
 	}
 
+	var out deploymentDTO
 	resp, err := c.resty.R().
 		SetContext(ctx).
-		SetBody(payload).
+		SetMultipartFormData(form).
+		SetFileReader("data", key+".bpmn", bytes.NewReader(xml)).
+		SetResult(&out).
 		Post("/deployment/create")
 	if err != nil {
-		return fmt.Errorf("call camunda: %w", err)
+		return flow.Deployment{}, fmt.Errorf("call camunda: %w", err)
 	}
 
 	if resp.IsError() {
-		return fmt.Errorf("camunda error: %s", resp.String())
+		return flow.Deployment{}, fmt.Errorf("camunda error: %s", resp.String())
+	}
+
+	deployment := flow.Deployment{ID: out.ID}
+	for _, definition := range out.DeployedProcessDefinitions {
+		if definition.Key == key {
+			deployment.ProcessDefinitionID = definition.ID
+		}
+	}
+	if deployment.ProcessDefinitionID == "" {
+		latest, err := c.latestDefinition(ctx, key, f.TenantID)
+		if err != nil {
+			return flow.Deployment{}, err
+		}
+		deployment.ProcessDefinitionID = latest.ID
 	}
 
-	return nil
+	return deployment, nil
 }
 
-func mustJSON(v any) string {
-	data, err := json.Marshal(v)
+func (c *Client) latestDefinition(ctx context.Context, key, tenantID string) (processDefinitionDTO, error) {
+	params := map[string]string{"key": key, "latestVersion": "true"}
+	if tenantID != "" {
+		params["tenantIdIn"] = tenantID
+	} else {
+		params["withoutTenantId"] = "true"
+	}
+
+	var out []processDefinitionDTO
+	resp, err := c.resty.R().
+		SetContext(ctx).
+		SetQueryParams(params).
+		SetResult(&out).
+		Get("/process-definition")
 	if err != nil {
-		panic(err)
+		return processDefinitionDTO{}, fmt.Errorf("call camunda: %w", err)
+	}
+	if resp.IsError() {
+		return processDefinitionDTO{}, fmt.Errorf("camunda error: %s", resp.String())
+	}
+	if len(out) == 0 {
+		return processDefinitionDTO{}, fmt.Errorf("camunda has no process definition for %s", key)
 	}
-	return string(data)
+	return out[0], nil
 }
@@ -0,0 +1,104 @@
+package camunda
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/migration"
+)
+
+type migrationInstructionDTO struct {
+	SourceActivityIDs  []string `json:"sourceActivityIds"`
+	TargetActivityIDs  []string `json:"targetActivityIds"`
+	UpdateEventTrigger bool     `json:"updateEventTrigger"`
+}
+
+type migrationPlanDTO struct {
+	SourceProcessDefinitionID string                    `json:"sourceProcessDefinitionId"`
+	TargetProcessDefinitionID string                    `json:"targetProcessDefinitionId"`
+	Instructions              []migrationInstructionDTO `json:"instructions"`
+}
+
+type migrationReportDTO struct {
+	InstructionReports []struct {
+		Instruction migrationInstructionDTO `json:"instruction"`
+		Failures    []string                `json:"failures"`
+	} `json:"instructionReports"`
+	VariableReports map[string]struct {
+		Failures []string `json:"failures"`
+	} `json:"variableReports"`
+}
+
+func (r *Runtime) ValidateMigration(ctx context.Context, plan migration.Plan) ([]string, error) {
+	var out migrationReportDTO
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetBody(toMigrationPlan(plan)).
+		SetResult(&out).
+		Post("/migration/validate")
+	if err != nil {
+		return nil, fmt.Errorf("validate migration: %w", err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("validate migration error: %s", resp.String())
+	}
+
+	problems := []string{}
+	for _, report := range out.InstructionReports {
+		for _, failure := range report.Failures {
+			problems = append(problems, fmt.Sprintf("%s -> %s: %s",
+				strings.Join(report.Instruction.SourceActivityIDs, ","),
+				strings.Join(report.Instruction.TargetActivityIDs, ","),
+				failure))
+		}
+	}
+
+	names := make([]string, 0, len(out.VariableReports))
+	for name := range out.VariableReports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, failure := range out.VariableReports[name].Failures {
+			problems = append(problems, fmt.Sprintf("variable %s: %s", name, failure))
+		}
+	}
+	return problems, nil
+}
+
+func (r *Runtime) ExecuteMigration(ctx context.Context, plan migration.Plan, processInstanceIDs []string) error {
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetBody(map[string]any{
+			"migrationPlan":       toMigrationPlan(plan),
+			"processInstanceIds":  processInstanceIDs,
+			"skipCustomListeners": false,
+			"skipIoMappings":      false,
+		}).
+		Post("/migration/execute")
+	if err != nil {
+		return fmt.Errorf("execute migration: %w", err)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("execute migration error: %s", resp.String())
+	}
+	return nil
+}
+
+func toMigrationPlan(plan migration.Plan) migrationPlanDTO {
+	dto := migrationPlanDTO{
+		SourceProcessDefinitionID: plan.SourceDefinitionID,
+		TargetProcessDefinitionID: plan.TargetDefinitionID,
+		Instructions:              make([]migrationInstructionDTO, len(plan.Instructions)),
+	}
+	for i, instruction := range plan.Instructions {
+		dto.Instructions[i] = migrationInstructionDTO{
+			SourceActivityIDs: []string{bpmn.ElementID(instruction.Source)},
+			TargetActivityIDs: []string{bpmn.ElementID(instruction.Target)},
+		}
+	}
+	return dto
+}
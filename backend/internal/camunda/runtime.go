@@ -2,9 +2,16 @@ package camunda
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-resty/resty/v2"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/task"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
 )
 
 type Runtime struct {
@@ -15,31 +22,565 @@ func NewRuntime(client *resty.Client) *Runtime {
 	return &Runtime{resty: client}
 }
 
-func (r *Runtime) StartProcess(ctx context.Context, flowID string, payload map[string]any) error {
+type processInstanceDTO struct {
+	ID           string `json:"id"`
+	DefinitionID string `json:"definitionId"`
+	BusinessKey  string `json:"businessKey"`
+	Suspended    bool   `json:"suspended"`
+	Ended        bool   `json:"ended"`
+}
+
+type historicProcessInstanceDTO struct {
+	ID                  string `json:"id"`
+	ProcessDefinitionID string `json:"processDefinitionId"`
+	BusinessKey         string `json:"businessKey"`
+	State               string `json:"state"`
+	StartTime           string `json:"startTime"`
+	EndTime             string `json:"endTime"`
+}
+
+type activityInstanceDTO struct {
+	ActivityID      string                `json:"activityId"`
+	ActivityType    string                `json:"activityType"`
+	ChildActivities []activityInstanceDTO `json:"childActivityInstances"`
+}
+
+type historicActivityDTO struct {
+	ID           string `json:"id"`
+	ActivityID   string `json:"activityId"`
+	ActivityName string `json:"activityName"`
+	ActivityType string `json:"activityType"`
+	Assignee     string `json:"assignee"`
+	TaskID       string `json:"taskId"`
+	StartTime    string `json:"startTime"`
+	EndTime      string `json:"endTime"`
+	Canceled     bool   `json:"canceled"`
+}
+
+type incidentDTO struct {
+	ID              string `json:"id"`
+	IncidentType    string `json:"incidentType"`
+	ActivityID      string `json:"activityId"`
+	IncidentMessage string `json:"incidentMessage"`
+	IncidentTime    string `json:"incidentTimestamp"`
+}
+
+type taskDTO struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	TaskDefinitionKey string `json:"taskDefinitionKey"`
+	Assignee          string `json:"assignee"`
+	Created           string `json:"created"`
+	Due               string `json:"due"`
+	Priority          int    `json:"priority"`
+	ProcessInstanceID string `json:"processInstanceId"`
+	TenantID          string `json:"tenantId"`
+}
+
+type identityLinkDTO struct {
+	UserID  string `json:"userId,omitempty"`
+	GroupID string `json:"groupId,omitempty"`
+	Type    string `json:"type"`
+}
+
+type retryTargetDTO struct {
+	ID string `json:"id"`
+}
+
+func (r *Runtime) StartProcess(ctx context.Context, processDefinitionID, businessKey string, payload map[string]any) (workorder.ProcessInstance, error) {
+	var out processInstanceDTO
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetBody(map[string]any{
+			"businessKey": businessKey,
+			"variables":   toVariables(payload),
+		}).
+		SetResult(&out).
+		Post(fmt.Sprintf("/process-definition/%s/start", processDefinitionID))
+	if err != nil {
+		return workorder.ProcessInstance{}, fmt.Errorf("start process: %w", err)
+	}
+	if resp.IsError() {
+		return workorder.ProcessInstance{}, fmt.Errorf("start process error: %s", resp.String())
+	}
+	return workorder.ProcessInstance{ID: out.ID, DefinitionID: out.DefinitionID, BusinessKey: out.BusinessKey}, nil
+}
+
+func (r *Runtime) RetryProcess(ctx context.Context, processInstanceID string) error {
+	for _, resource := range []string{"job", "external-task"} {
+		var targets []retryTargetDTO
+		resp, err := r.resty.R().
+			SetContext(ctx).
+			SetQueryParams(map[string]string{
+				"processInstanceId": processInstanceID,
+				"noRetriesLeft":     "true",
+			}).
+			SetResult(&targets).
+			Get("/" + resource)
+		if err != nil {
+			return fmt.Errorf("retry process: list %s: %w", resource, err)
+		}
+		if resp.IsError() {
+			return fmt.Errorf("retry process error: %s", resp.String())
+		}
+
+		for _, target := range targets {
+			resp, err := r.resty.R().
+				SetContext(ctx).
+				SetBody(map[string]any{"retries": 1}).
+				Put(fmt.Sprintf("/%s/%s/retries", resource, target.ID))
+			if err != nil {
+				return fmt.Errorf("retry process: %s %s: %w", resource, target.ID, err)
+			}
+			if resp.IsError() {
+				return fmt.Errorf("retry process error: %s", resp.String())
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) CancelProcess(ctx context.Context, processInstanceID, reason string) error {
 	resp, err := r.resty.R().
 		SetContext(ctx).
 		SetBody(map[string]any{
-			"variables": payload,
+			"processInstanceIds":  []string{processInstanceID},
+			"deleteReason":        reason,
+			"skipCustomListeners": false,
 		}).
-		Post(fmt.Sprintf("/process-definition/key/%s/start", flowID))
+		Post("/process-instance/delete")
+	if err != nil {
+		return fmt.Errorf("cancel process: %w", err)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("cancel process error: %s", resp.String())
+	}
+	return nil
+}
+
+func (r *Runtime) SuspendProcess(ctx context.Context, processInstanceID string) error {
+	return r.setSuspended(ctx, processInstanceID, true)
+}
+
+func (r *Runtime) ResumeProcess(ctx context.Context, processInstanceID string) error {
+	return r.setSuspended(ctx, processInstanceID, false)
+}
+
+func (r *Runtime) setSuspended(ctx context.Context, processInstanceID string, suspended bool) error {
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetBody(map[string]any{"suspended": suspended}).
+		Put(fmt.Sprintf("/process-instance/%s/suspended", processInstanceID))
+	if err != nil {
+		return fmt.Errorf("update process suspension: %w", err)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("update process suspension error: %s", resp.String())
+	}
+	return nil
+}
+
+func (r *Runtime) SetProcessVariable(ctx context.Context, processInstanceID, name string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal variable %s: %w", name, err)
+	}
+
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetBody(map[string]any{"value": string(data), "type": "Json"}).
+		Put(fmt.Sprintf("/process-instance/%s/variables/%s", processInstanceID, name))
 	if err != nil {
-		return fmt.Errorf("start process: %w", err)
+		return fmt.Errorf("set process variable: %w", err)
 	}
 	if resp.IsError() {
-		return fmt.Errorf("start process error: %s", resp.String())
+		return fmt.Errorf("set process variable error: %s", resp.String())
 	}
 	return nil
 }
 
-func (r *Runtime) RetryProcess(ctx context.Context, workOrderID string) error {
+func (r *Runtime) InspectProcess(ctx context.Context, processInstanceID string) (workorder.ProcessState, error) {
+	var history historicProcessInstanceDTO
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetResult(&history).
+		Get(fmt.Sprintf("/history/process-instance/%s", processInstanceID))
+	if err != nil {
+		return workorder.ProcessState{}, fmt.Errorf("inspect process: %w", err)
+	}
+	if resp.IsError() {
+		return workorder.ProcessState{}, fmt.Errorf("inspect process error: %s", resp.String())
+	}
+
+	state := workorder.ProcessState{
+		ProcessInstance: workorder.ProcessInstance{
+			ID:           history.ID,
+			DefinitionID: history.ProcessDefinitionID,
+			BusinessKey:  history.BusinessKey,
+		},
+		State:            history.State,
+		Suspended:        history.State == "SUSPENDED",
+		Ended:            history.EndTime != "",
+		StartTime:        parseTime(history.StartTime),
+		EndTime:          parseTime(history.EndTime),
+		ActiveActivities: []string{},
+		UserTasks:        []workorder.UserTask{},
+		Incidents:        []workorder.Incident{},
+	}
+
+	if !state.Ended {
+		var tree activityInstanceDTO
+		resp, err := r.resty.R().
+			SetContext(ctx).
+			SetResult(&tree).
+			Get(fmt.Sprintf("/process-instance/%s/activity-instances", processInstanceID))
+		if err != nil {
+			return workorder.ProcessState{}, fmt.Errorf("inspect activities: %w", err)
+		}
+		if resp.IsError() && resp.StatusCode() != http.StatusNotFound {
+			return workorder.ProcessState{}, fmt.Errorf("inspect activities error: %s", resp.String())
+		}
+		state.ActiveActivities = leafActivities(tree, state.ActiveActivities)
+
+		tasks, err := r.listTasks(ctx, processInstanceID)
+		if err != nil {
+			return workorder.ProcessState{}, err
+		}
+		for _, task := range tasks {
+			userTask := workorder.UserTask{
+				ID:         task.ID,
+				Name:       task.Name,
+				ActivityID: task.TaskDefinitionKey,
+				Assignee:   task.Assignee,
+			}
+			if t := parseTime(task.Created); t != nil {
+				userTask.CreatedAt = *t
+			}
+			state.UserTasks = append(state.UserTasks, userTask)
+		}
+	}
+
+	var incidents []incidentDTO
+	resp, err = r.resty.R().
+		SetContext(ctx).
+		SetQueryParam("processInstanceId", processInstanceID).
+		SetResult(&incidents).
+		Get("/incident")
+	if err != nil {
+		return workorder.ProcessState{}, fmt.Errorf("inspect incidents: %w", err)
+	}
+	if resp.IsError() {
+		return workorder.ProcessState{}, fmt.Errorf("inspect incidents error: %s", resp.String())
+	}
+	for _, inc := range incidents {
+		incident := workorder.Incident{
+			ID:         inc.ID,
+			Type:       inc.IncidentType,
+			ActivityID: inc.ActivityID,
+			Message:    inc.IncidentMessage,
+		}
+		if t := parseTime(inc.IncidentTime); t != nil {
+			incident.CreatedAt = *t
+		}
+		state.Incidents = append(state.Incidents, incident)
+	}
+
+	return state, nil
+}
+
+func (r *Runtime) ActivityHistory(ctx context.Context, processInstanceID string) ([]workorder.Activity, error) {
+	var history []historicActivityDTO
 	resp, err := r.resty.R().
 		SetContext(ctx).
-		Post(fmt.Sprintf("/external-task/%s/retry", workOrderID))
+		SetQueryParams(map[string]string{
+			"processInstanceId": processInstanceID,
+			"sortBy":            "startTime",
+			"sortOrder":         "asc",
+		}).
+		SetResult(&history).
+		Get("/history/activity-instance")
 	if err != nil {
-		return fmt.Errorf("retry process: %w", err)
+		return nil, fmt.Errorf("activity history: %w", err)
 	}
 	if resp.IsError() {
-		return fmt.Errorf("retry process error: %s", resp.String())
+		return nil, fmt.Errorf("activity history error: %s", resp.String())
+	}
+
+	activities := make([]workorder.Activity, 0, len(history))
+	for _, h := range history {
+		activity := workorder.Activity{
+			ID:         h.ID,
+			ActivityID: h.ActivityID,
+			Name:       h.ActivityName,
+			Type:       h.ActivityType,
+			Assignee:   h.Assignee,
+			TaskID:     h.TaskID,
+			EndTime:    parseTime(h.EndTime),
+			Cancelled:  h.Canceled,
+		}
+		if t := parseTime(h.StartTime); t != nil {
+			activity.StartTime = *t
+		}
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
+
+func (r *Runtime) AssignTasks(ctx context.Context, processInstanceID string, assignment workorder.TaskAssignment) error {
+	tasks, err := r.listTasks(ctx, processInstanceID)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if task.Assignee != assignment.Assignee {
+			var userID any
+			if assignment.Assignee != "" {
+				userID = assignment.Assignee
+			}
+			resp, err := r.resty.R().
+				SetContext(ctx).
+				SetBody(map[string]any{"userId": userID}).
+				Post(fmt.Sprintf("/task/%s/assignee", task.ID))
+			if err != nil {
+				return fmt.Errorf("assign task %s: %w", task.ID, err)
+			}
+			if resp.IsError() {
+				return fmt.Errorf("assign task error: %s", resp.String())
+			}
+		}
+
+		links := make([]identityLinkDTO, 0, len(assignment.Candidates.Users)+len(assignment.Candidates.Groups))
+		for _, user := range assignment.Candidates.Users {
+			links = append(links, identityLinkDTO{UserID: user, Type: "candidate"})
+		}
+		for _, group := range assignment.Candidates.Groups {
+			links = append(links, identityLinkDTO{GroupID: group, Type: "candidate"})
+		}
+		if err := r.replaceCandidates(ctx, task.ID, links); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) ListTasks(ctx context.Context, q task.Query) ([]task.Task, error) {
+	criteria := map[string]any{}
+	if q.Assignee != "" {
+		criteria["assignee"] = q.Assignee
+	}
+	if q.CandidateUser != "" {
+		criteria["candidateUser"] = q.CandidateUser
+	}
+	if len(q.CandidateGroups) > 0 {
+		criteria["candidateGroups"] = q.CandidateGroups
+	}
+	if q.IncludeAssigned {
+		criteria["includeAssignedTasks"] = true
+	}
+
+	body := map[string]any{
+		"sorting": []map[string]string{{"sortBy": "created", "sortOrder": "desc"}},
+	}
+	if q.AnyOf {
+		body["orQueries"] = []map[string]any{criteria}
+	} else {
+		for key, value := range criteria {
+			body[key] = value
+		}
+	}
+	if q.Unassigned {
+		body["unassigned"] = true
+	}
+	if q.TenantID != "" {
+		body["tenantIdIn"] = []string{q.TenantID}
+	}
+
+	var tasks []taskDTO
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"firstResult": strconv.Itoa(q.FirstResult),
+			"maxResults":  strconv.Itoa(q.MaxResults),
+		}).
+		SetBody(body).
+		SetResult(&tasks).
+		Post("/task")
+	if err != nil {
+		return nil, fmt.Errorf("query tasks: %w", err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("query tasks error: %s", resp.String())
+	}
+
+	result := make([]task.Task, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, toTask(t))
+	}
+	return result, nil
+}
+
+func (r *Runtime) GetTask(ctx context.Context, id string) (task.Task, bool, error) {
+	var out taskDTO
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetResult(&out).
+		Get(fmt.Sprintf("/task/%s", id))
+	if err != nil {
+		return task.Task{}, false, fmt.Errorf("get task: %w", err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return task.Task{}, false, nil
+	}
+	if resp.IsError() {
+		return task.Task{}, false, fmt.Errorf("get task error: %s", resp.String())
+	}
+	return toTask(out), true, nil
+}
+
+func (r *Runtime) CompleteTask(ctx context.Context, id string, variables map[string]any) error {
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetBody(map[string]any{"variables": toVariables(variables)}).
+		Post(fmt.Sprintf("/task/%s/complete", id))
+	if err != nil {
+		return fmt.Errorf("complete task: %w", err)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("complete task error: %s", resp.String())
+	}
+	return nil
+}
+
+func toTask(t taskDTO) task.Task {
+	result := task.Task{
+		ID:                t.ID,
+		Name:              t.Name,
+		ActivityID:        t.TaskDefinitionKey,
+		Assignee:          t.Assignee,
+		ProcessInstanceID: t.ProcessInstanceID,
+		TenantID:          t.TenantID,
+		Priority:          t.Priority,
+		Due:               parseTime(t.Due),
+	}
+	if created := parseTime(t.Created); created != nil {
+		result.CreatedAt = *created
+	}
+	return result
+}
+
+func (r *Runtime) listTasks(ctx context.Context, processInstanceID string) ([]taskDTO, error) {
+	var tasks []taskDTO
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetQueryParam("processInstanceId", processInstanceID).
+		SetResult(&tasks).
+		Get("/task")
+	if err != nil {
+		return nil, fmt.Errorf("list tasks: %w", err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("list tasks error: %s", resp.String())
+	}
+	return tasks, nil
+}
+
+func (r *Runtime) replaceCandidates(ctx context.Context, taskID string, links []identityLinkDTO) error {
+	var existing []identityLinkDTO
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetQueryParam("type", "candidate").
+		SetResult(&existing).
+		Get(fmt.Sprintf("/task/%s/identity-links", taskID))
+	if err != nil {
+		return fmt.Errorf("list identity links: %w", err)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("list identity links error: %s", resp.String())
+	}
+
+	wanted := make(map[identityLinkDTO]bool, len(links))
+	for _, link := range links {
+		wanted[link] = true
+	}
+	current := make(map[identityLinkDTO]bool, len(existing))
+	for _, link := range existing {
+		current[link] = true
+		if !wanted[link] {
+			if err := r.postIdentityLink(ctx, taskID, "delete", link); err != nil {
+				return err
+			}
+		}
+	}
+	for _, link := range links {
+		if !current[link] {
+			if err := r.postIdentityLink(ctx, taskID, "", link); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) postIdentityLink(ctx context.Context, taskID, action string, link identityLinkDTO) error {
+	path := fmt.Sprintf("/task/%s/identity-links", taskID)
+	if action != "" {
+		path += "/" + action
+	}
+	resp, err := r.resty.R().
+		SetContext(ctx).
+		SetBody(link).
+		Post(path)
+	if err != nil {
+		return fmt.Errorf("update identity link: %w", err)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("update identity link error: %s", resp.String())
+	}
+	return nil
+}
+
+func leafActivities(node activityInstanceDTO, acc []string) []string {
+	if len(node.ChildActivities) == 0 {
+		if node.ActivityType != "processDefinition" && node.ActivityID != "" {
+			acc = append(acc, node.ActivityID)
+		}
+		return acc
+	}
+	for _, child := range node.ChildActivities {
+		acc = leafActivities(child, acc)
+	}
+	return acc
+}
+
+func toVariables(payload map[string]any) map[string]any {
+	variables := make(map[string]any, len(payload))
+	for name, value := range payload {
+		switch value.(type) {
+		case map[string]any, []any:
+			data, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			variables[name] = map[string]any{"value": string(data), "type": "Json"}
+		default:
+			variables[name] = map[string]any{"value": value}
+		}
+	}
+	return variables
+}
+
+const camundaTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+func parseTime(raw string) *time.Time {
+	if raw == "" {
+		return nil
+	}
+	for _, layout := range []string{camundaTimeLayout, time.RFC3339Nano} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			t = t.UTC()
+			return &t
+		}
 	}
 	return nil
 }
@@ -0,0 +1,181 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+type DeployStatus string
+
+const (
+	DeployNone     DeployStatus = "none"
+	DeployPending  DeployStatus = "pending"
+	DeployDeployed DeployStatus = "deployed"
+	DeployFailed   DeployStatus = "failed"
+)
+
+func (s DeployStatus) Valid() bool {
+	switch s {
+	case DeployNone, DeployPending, DeployDeployed, DeployFailed:
+		return true
+	}
+	return false
+}
+
+type Deployment struct {
+	ID                  string
+	ProcessDefinitionID string
+}
+
+type DeployRepository interface {
+	LockDeployDue(ctx context.Context, now time.Time, limit int) ([]Flow, error)
+	GetVersion(ctx context.Context, flowID string, version int) (Snapshot, error)
+	MarkDeployed(ctx context.Context, id string, version int, deployment Deployment, at time.Time) (Flow, error)
+	MarkDeployFailed(ctx context.Context, id string, version int, cause string, next time.Time, maxAttempts int) (Flow, error)
+	ResetDeploy(ctx context.Context, id string, at time.Time) (Flow, error)
+}
+
+type DeployError struct {
+	Flow Flow
+	Err  error
+}
+
+func (e *DeployError) Error() string {
+	return fmt.Sprintf("deploy flow %s version %d to camunda: %v", e.Flow.ID, e.Flow.PublishedVersion, e.Err)
+}
+
+func (e *DeployError) Unwrap() error { return e.Err }
+
+func AsDeployError(err error) (*DeployError, bool) {
+	var target *DeployError
+	if errors.As(err, &target) {
+		return target, true
+	}
+	return nil, false
+}
+
+type deployer struct {
+	repo    DeployRepository
+	camunda CamundaDeployer
+	retry   config.RetryConfig
+}
+
+func (d deployer) deploy(ctx context.Context, f Flow, xml []byte) (Flow, error) {
+	snapshot, err := d.repo.GetVersion(ctx, f.ID, f.PublishedVersion)
+	if err != nil {
+		return f, fmt.Errorf("load published version: %w", err)
+	}
+
+	target := f
+	target.Name = snapshot.Name
+	target.Description = snapshot.Description
+	target.Definition = snapshot.Definition
+	target.Metadata = snapshot.Metadata
+	target.Version = snapshot.Version
+
+	if xml == nil {
+		xml, err = bpmn.Compile(bpmn.Process{Key: bpmn.ProcessKey(f.ID), Name: target.Name, Definition: target.Definition})
+		if err != nil {
+			return f, fmt.Errorf("compile published version: %w", err)
+		}
+	}
+
+	deployment, err := d.camunda.Deploy(ctx, target, xml)
+	if err != nil {
+		next := time.Now().UTC().Add(d.retry.Delay(f.DeployAttempts))
+		updated, markErr := d.repo.MarkDeployFailed(ctx, f.ID, f.PublishedVersion, err.Error(), next, d.retry.MaxAttempts)
+		switch {
+		case errors.Is(markErr, sqlErrVersionConflict):
+			return f, &DeployError{Flow: f, Err: err}
+		case markErr != nil:
+			return f, fmt.Errorf("record deploy failure: %w (deploy err: %v)", markErr, err)
+		}
+		return updated, &DeployError{Flow: updated, Err: err}
+	}
+
+	updated, err := d.repo.MarkDeployed(ctx, f.ID, f.PublishedVersion, deployment, time.Now().UTC())
+	if errors.Is(err, sqlErrVersionConflict) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	return updated, nil
+}
+
+type DeployRetrier struct {
+	repo     DeployRepository
+	tx       persistence.Transactor
+	deployer deployer
+	cfg      config.RetryConfig
+}
+
+func NewDeployRetrier(repo DeployRepository, tx persistence.Transactor, camunda CamundaDeployer, cfg config.RetryConfig) *DeployRetrier {
+	return &DeployRetrier{repo: repo, tx: tx, deployer: deployer{repo: repo, camunda: camunda, retry: cfg}, cfg: cfg}
+}
+
+func (r *DeployRetrier) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.RetryOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("flow deploy retry: %v", err)
+		}
+		if n == r.cfg.BatchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *DeployRetrier) RetryOnce(ctx context.Context) (int, error) {
+	var processed int
+	var failures []error
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		flows, err := r.repo.LockDeployDue(ctx, time.Now().UTC(), r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range flows {
+			ctx := tenant.WithTenant(ctx, f.TenantID)
+			err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+				_, err := r.deployer.deploy(ctx, f, nil)
+				if deployErr, ok := AsDeployError(err); ok {
+					log.Printf("flow deploy retry: %v", deployErr)
+					return nil
+				}
+				return err
+			})
+			if err != nil {
+				failures = append(failures, fmt.Errorf("%s: %w", f.ID, err))
+				next := time.Now().UTC().Add(r.cfg.Delay(f.DeployAttempts))
+				if _, markErr := r.repo.MarkDeployFailed(ctx, f.ID, f.PublishedVersion, err.Error(), next, r.cfg.MaxAttempts); markErr != nil && !errors.Is(markErr, sqlErrVersionConflict) {
+					failures = append(failures, fmt.Errorf("%s: %w", f.ID, markErr))
+				}
+			}
+		}
+
+		processed = len(flows)
+		return nil
+	})
+	if err != nil {
+		return processed, err
+	}
+	return processed, errors.Join(failures...)
+}
@@ -0,0 +1,139 @@
+package flow
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type ElementChange struct {
+	ID     string         `json:"id"`
+	Before map[string]any `json:"before,omitempty"`
+	After  map[string]any `json:"after,omitempty"`
+}
+
+type ElementDiff struct {
+	Added   []ElementChange `json:"added"`
+	Removed []ElementChange `json:"removed"`
+	Changed []ElementChange `json:"changed"`
+}
+
+type ValueChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type MetadataDiff struct {
+	Added   map[string]string      `json:"added"`
+	Removed map[string]string      `json:"removed"`
+	Changed map[string]ValueChange `json:"changed"`
+}
+
+type VersionDiff struct {
+	FlowID      string       `json:"flowId"`
+	From        int          `json:"from"`
+	To          int          `json:"to"`
+	Name        *ValueChange `json:"name,omitempty"`
+	Description *ValueChange `json:"description,omitempty"`
+	Nodes       ElementDiff  `json:"nodes"`
+	Edges       ElementDiff  `json:"edges"`
+	Metadata    MetadataDiff `json:"metadata"`
+}
+
+func Diff(from, to Snapshot) VersionDiff {
+	diff := VersionDiff{
+		FlowID:   to.FlowID,
+		From:     from.Version,
+		To:       to.Version,
+		Nodes:    diffElements(elementsByID(from.Definition, "nodes"), elementsByID(to.Definition, "nodes")),
+		Edges:    diffElements(elementsByID(from.Definition, "edges"), elementsByID(to.Definition, "edges")),
+		Metadata: diffMetadata(from.Metadata, to.Metadata),
+	}
+
+	if from.Name != to.Name {
+		diff.Name = &ValueChange{Before: from.Name, After: to.Name}
+	}
+	if from.Description != to.Description {
+		diff.Description = &ValueChange{Before: from.Description, After: to.Description}
+	}
+
+	return diff
+}
+
+func elementsByID(definition map[string]any, key string) map[string]map[string]any {
+	result := make(map[string]map[string]any)
+	items, _ := definition[key].([]any)
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		id, _ := obj["id"].(string)
+		if id == "" {
+			id = fmt.Sprintf("#%d", i)
+		}
+		result[id] = obj
+	}
+	return result
+}
+
+func diffElements(before, after map[string]map[string]any) ElementDiff {
+	diff := ElementDiff{
+		Added:   []ElementChange{},
+		Removed: []ElementChange{},
+		Changed: []ElementChange{},
+	}
+
+	for _, id := range sortedKeys(after) {
+		prev, ok := before[id]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, ElementChange{ID: id, After: after[id]})
+		case !reflect.DeepEqual(prev, after[id]):
+			diff.Changed = append(diff.Changed, ElementChange{ID: id, Before: prev, After: after[id]})
+		}
+	}
+
+	for _, id := range sortedKeys(before) {
+		if _, ok := after[id]; !ok {
+			diff.Removed = append(diff.Removed, ElementChange{ID: id, Before: before[id]})
+		}
+	}
+
+	return diff
+}
+
+func diffMetadata(before, after map[string]string) MetadataDiff {
+	diff := MetadataDiff{
+		Added:   map[string]string{},
+		Removed: map[string]string{},
+		Changed: map[string]ValueChange{},
+	}
+
+	for key, value := range after {
+		prev, ok := before[key]
+		switch {
+		case !ok:
+			diff.Added[key] = value
+		case prev != value:
+			diff.Changed[key] = ValueChange{Before: prev, After: value}
+		}
+	}
+
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			diff.Removed[key] = value
+		}
+	}
+
+	return diff
+}
+
+func sortedKeys(m map[string]map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,135 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
+)
+
+type Status string
+
+const (
+	StatusDraft      Status = "draft"
+	StatusPublished  Status = "published"
+	StatusDeprecated Status = "deprecated"
+)
+
+func (s Status) Valid() bool {
+	switch s {
+	case StatusDraft, StatusPublished, StatusDeprecated:
+		return true
+	}
+	return false
+}
+
+type LifecycleError struct {
+	ID     string
+	Status Status
+	Action string
+}
+
+func (e *LifecycleError) Error() string {
+	return fmt.Sprintf("flow %s is %s and cannot be %s", e.ID, e.Status, e.Action)
+}
+
+func IsLifecycleConflict(err error) bool {
+	var target *LifecycleError
+	return errors.As(err, &target)
+}
+
+func (s *service) Publish(ctx context.Context, id string, version int) (Flow, error) {
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return Flow{}, err
+	}
+	if version == 0 {
+		version = current.Version
+	}
+	if version <= current.PublishedVersion {
+		return Flow{}, &LifecycleError{ID: id, Status: current.Status, Action: "published"}
+	}
+
+	snapshot, err := s.GetVersion(ctx, id, version)
+	if err != nil {
+		return Flow{}, err
+	}
+	compiled, err := bpmn.Compile(bpmn.Process{Key: bpmn.ProcessKey(id), Name: snapshot.Name, Definition: snapshot.Definition})
+	if err != nil {
+		return Flow{}, err
+	}
+
+	var published Flow
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		published, err = s.repo.Publish(ctx, id, version, time.Now().UTC())
+		if err != nil {
+			if errors.Is(err, sqlErrStatusConflict) {
+				return &LifecycleError{ID: id, Status: current.Status, Action: "published"}
+			}
+			return err
+		}
+
+		if s.publisher != nil {
+			if err := s.publisher.PublishFlowPublished(ctx, published); err != nil {
+				return fmt.Errorf("publish flow published: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Flow{}, err
+	}
+
+	if s.camunda == nil {
+		return published, nil
+	}
+	deployed, err := s.deployer.deploy(ctx, published, compiled)
+	if err != nil {
+		return Flow{}, err
+	}
+	return deployed, nil
+}
+
+func (s *service) Deprecate(ctx context.Context, id string) (Flow, error) {
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return Flow{}, err
+	}
+	switch current.Status {
+	case StatusDeprecated:
+		return current, nil
+	case StatusDraft:
+		return Flow{}, &LifecycleError{ID: id, Status: current.Status, Action: "deprecated"}
+	}
+
+	var deprecated Flow
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		deprecated, err = s.repo.UpdateStatus(ctx, id, StatusPublished, StatusDeprecated, time.Now().UTC())
+		if err != nil {
+			if errors.Is(err, sqlErrStatusConflict) {
+				return &LifecycleError{ID: id, Status: current.Status, Action: "deprecated"}
+			}
+			return err
+		}
+
+		if s.publisher != nil {
+			if err := s.publisher.PublishFlowDeprecated(ctx, deprecated); err != nil {
+				return fmt.Errorf("publish flow deprecated: %w", err)
+			}
+		}
+		return nil
+	})
+	if IsLifecycleConflict(err) {
+		if latest, getErr := s.Get(ctx, id); getErr == nil && latest.Status == StatusDeprecated {
+			return latest, nil
+		}
+	}
+	if err != nil {
+		return Flow{}, err
+	}
+	return deprecated, nil
+}
@@ -3,22 +3,73 @@ package flow
 import "time"
 
 type Flow struct {
-	ID          string            `json:"id" db:"id"`
-	Name        string            `json:"name" db:"name"`
-	Description string            `json:"description" db:"description"`
-	Definition  map[string]any    `json:"definition" db:"definition"`
-	Metadata    map[string]string `json:"metadata" db:"metadata"`
-	Version     int               `json:"version" db:"version"`
-	CreatedAt   time.Time         `json:"createdAt" db:"created_at"`
-	UpdatedAt   time.Time         `json:"updatedAt" db:"updated_at"`
+	ID               string            `json:"id" db:"id"`
+	TenantID         string            `json:"tenantId" db:"tenant_id"`
+	Name             string            `json:"name" db:"name"`
+	Description      string            `json:"description" db:"description"`
+	Definition       map[string]any    `json:"definition" db:"definition"`
+	Metadata         map[string]string `json:"metadata" db:"metadata"`
+	Version          int               `json:"version" db:"version"`
+	Status           Status            `json:"status" db:"status"`
+	PublishedVersion int               `json:"publishedVersion,omitempty" db:"published_version"`
+	DeployStatus     DeployStatus      `json:"deployStatus" db:"deploy_status"`
+	DeployAttempts   int               `json:"deployAttempts" db:"deploy_attempts"`
+	DeployError      string            `json:"deployError,omitempty" db:"deploy_error"`
+	NextDeployAt     *time.Time        `json:"nextDeployAt,omitempty" db:"next_deploy_at"`
+	DeployedAt       *time.Time        `json:"deployedAt,omitempty" db:"deployed_at"`
+	CreatedAt        time.Time         `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time         `json:"updatedAt" db:"updated_at"`
 }
 
-func (f Flow) Summary() map[string]any {
-	return map[string]any{
-		"id":          f.ID,
-		"name":        f.Name,
-		"description": f.Description,
-		"version":     f.Version,
-		"updatedAt":   f.UpdatedAt,
+type Snapshot struct {
+	FlowID              string            `json:"flowId" db:"flow_id"`
+	Version             int               `json:"version" db:"version"`
+	Name                string            `json:"name" db:"name"`
+	Description         string            `json:"description" db:"description"`
+	Definition          map[string]any    `json:"definition" db:"definition"`
+	Metadata            map[string]string `json:"metadata" db:"metadata"`
+	DeploymentID        string            `json:"deploymentId,omitempty" db:"deployment_id"`
+	ProcessDefinitionID string            `json:"processDefinitionId,omitempty" db:"process_definition_id"`
+	PublishedAt         *time.Time        `json:"publishedAt,omitempty" db:"published_at"`
+	CreatedAt           time.Time         `json:"createdAt" db:"created_at"`
+}
+
+func (f Flow) Snapshot() Snapshot {
+	return Snapshot{
+		FlowID:      f.ID,
+		Version:     f.Version,
+		Name:        f.Name,
+		Description: f.Description,
+		Definition:  f.Definition,
+		Metadata:    f.Metadata,
+		CreatedAt:   f.UpdatedAt,
+	}
+}
+
+type Summary struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	Metadata         map[string]string `json:"metadata"`
+	Version          int               `json:"version"`
+	Status           Status            `json:"status"`
+	PublishedVersion int               `json:"publishedVersion,omitempty"`
+	DeployStatus     DeployStatus      `json:"deployStatus"`
+	DeployError      string            `json:"deployError,omitempty"`
+	UpdatedAt        time.Time         `json:"updatedAt"`
+}
+
+func (f Flow) Summary() Summary {
+	return Summary{
+		ID:               f.ID,
+		Name:             f.Name,
+		Description:      f.Description,
+		Metadata:         f.Metadata,
+		Version:          f.Version,
+		Status:           f.Status,
+		PublishedVersion: f.PublishedVersion,
+		DeployStatus:     f.DeployStatus,
+		DeployError:      f.DeployError,
+		UpdatedAt:        f.UpdatedAt,
 	}
 }
@@ -0,0 +1,187 @@
+package flow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+type SortField string
+
+const (
+	SortUpdatedAt SortField = "updatedAt"
+	SortCreatedAt SortField = "createdAt"
+	SortName      SortField = "name"
+)
+
+var sortColumns = map[SortField]string{
+	SortUpdatedAt: "updated_at",
+	SortCreatedAt: "created_at",
+	SortName:      "name",
+}
+
+type ListFilter struct {
+	Search            string
+	Statuses          []Status
+	DeployStatuses    []DeployStatus
+	Metadata          map[string]string
+	IncludeDefinition bool
+	Sort              SortField
+	Ascending         bool
+	Limit             int
+	Cursor            string
+}
+
+type Page struct {
+	Items      []Flow `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type QueryError struct {
+	Field   string
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+func IsInvalidQuery(err error) bool {
+	var target *QueryError
+	return errors.As(err, &target)
+}
+
+func (f ListFilter) normalize() (ListFilter, error) {
+	if f.Sort == "" {
+		f.Sort = SortUpdatedAt
+	}
+	if _, ok := sortColumns[f.Sort]; !ok {
+		return ListFilter{}, &QueryError{Field: "sort", Message: fmt.Sprintf("unsupported sort field %q", f.Sort)}
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+	f.Search = strings.TrimSpace(f.Search)
+	for _, status := range f.Statuses {
+		if !status.Valid() {
+			return ListFilter{}, &QueryError{Field: "status", Message: fmt.Sprintf("unknown status %q", status)}
+		}
+	}
+	for _, status := range f.DeployStatuses {
+		if !status.Valid() {
+			return ListFilter{}, &QueryError{Field: "deployStatus", Message: fmt.Sprintf("unknown deploy status %q", status)}
+		}
+	}
+	return f, nil
+}
+
+func (f ListFilter) cursorKey() string {
+	if f.Ascending {
+		return string(f.Sort) + ":asc"
+	}
+	return string(f.Sort) + ":desc"
+}
+
+type listQuery struct {
+	where []string
+	args  []any
+}
+
+func (q *listQuery) add(clause string, args ...any) {
+	for _, arg := range args {
+		q.args = append(q.args, arg)
+		clause = strings.Replace(clause, "?", fmt.Sprintf("$%d", len(q.args)), 1)
+	}
+	q.where = append(q.where, clause)
+}
+
+func addIn[T ~string](q *listQuery, column string, values []T) {
+	placeholders := make([]string, len(values))
+	args := make([]any, len(values))
+	for i, value := range values {
+		placeholders[i] = "?"
+		args[i] = value
+	}
+	q.add(column+" IN ("+strings.Join(placeholders, ", ")+")", args...)
+}
+
+func buildListQuery(tenantID string, f ListFilter) (string, []any, error) {
+	var q listQuery
+
+	q.add("tenant_id = ?", tenantID)
+
+	if f.Search != "" {
+		q.add("(search_vector @@ plainto_tsquery('simple', ?) OR name ILIKE ? OR description ILIKE ?)", f.Search, "%"+f.Search+"%", "%"+f.Search+"%")
+	}
+	if len(f.Statuses) > 0 {
+		addIn(&q, "status", f.Statuses)
+	}
+	if len(f.DeployStatuses) > 0 {
+		addIn(&q, "deploy_status", f.DeployStatuses)
+	}
+	if len(f.Metadata) > 0 {
+		data, err := json.Marshal(f.Metadata)
+		if err != nil {
+			return "", nil, fmt.Errorf("marshal metadata filter: %w", err)
+		}
+		q.add("metadata @> ?::jsonb", string(data))
+	}
+
+	column := sortColumns[f.Sort]
+	direction, comparator := "DESC", "<"
+	if f.Ascending {
+		direction, comparator = "ASC", ">"
+	}
+
+	if f.Cursor != "" {
+		cursor, err := persistence.DecodeCursor(f.Cursor)
+		if err != nil || cursor.Sort != f.cursorKey() {
+			return "", nil, &QueryError{Field: "cursor", Message: "cursor does not match this query"}
+		}
+		var value any = cursor.Value
+		if f.Sort != SortName {
+			t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return "", nil, &QueryError{Field: "cursor", Message: "malformed cursor value"}
+			}
+			value = t
+		}
+		q.add(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparator), value, cursor.ID)
+	}
+
+	definition := "NULL::jsonb"
+	if f.IncludeDefinition {
+		definition = "definition"
+	}
+
+	query := `SELECT ` + flowColumns(definition) + ` FROM flows WHERE ` + strings.Join(q.where, " AND ")
+	q.args = append(q.args, f.Limit+1)
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT $%d", column, direction, direction, len(q.args))
+
+	return query, q.args, nil
+}
+
+func nextCursor(f ListFilter, last Flow) string {
+	var value string
+	switch f.Sort {
+	case SortName:
+		value = last.Name
+	case SortCreatedAt:
+		value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		value = last.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return persistence.EncodeCursor(persistence.Cursor{Sort: f.cursorKey(), Value: value, ID: last.ID})
+}
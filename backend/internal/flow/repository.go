@@ -11,8 +11,15 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
 )
 
+func flowColumns(definition string) string {
+	return `id, tenant_id, name, description, ` + definition + `, metadata, version, status, COALESCE(published_version, 0), deploy_status, deploy_attempts, deploy_error, next_deploy_at, deployed_at, created_at, updated_at`
+}
+
+const snapshotColumns = `flow_id, version, name, description, definition, metadata, COALESCE(deployment_id, ''), COALESCE(process_definition_id, ''), published_at, created_at`
+
 type repository struct {
 	db *sqlx.DB
 }
@@ -21,51 +28,52 @@ func NewRepository(db *sqlx.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) List(ctx context.Context) ([]Flow, error) {
-	const query = `SELECT id, name, description, definition, metadata, version, created_at, updated_at FROM flows ORDER BY updated_at DESC`
+func (r *repository) List(ctx context.Context, filter ListFilter) (Page, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+
+	query, args, err := buildListQuery(tenantID, filter)
+	if err != nil {
+		return Page{}, err
+	}
 
-	rows, err := r.db.QueryxContext(ctx, query)
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list flows: %w", err)
+		return Page{}, fmt.Errorf("list flows: %w", err)
 	}
 	defer rows.Close()
 
-	var flows []Flow
+	page := Page{Items: []Flow{}}
 	for rows.Next() {
-		var (
-			f           Flow
-			definition  []byte
-			metadataRaw []byte
-		)
-		if err := rows.Scan(&f.ID, &f.Name, &f.Description, &definition, &metadataRaw, &f.Version, &f.CreatedAt, &f.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("scan flow: %w", err)
-		}
-		if len(definition) > 0 {
-			if err := json.Unmarshal(definition, &f.Definition); err != nil {
-				return nil, fmt.Errorf("unmarshal definition: %w", err)
-			}
+		f, err := scanFlow(rows)
+		if err != nil {
+			return Page{}, err
 		}
-		if len(metadataRaw) > 0 {
-			if err := json.Unmarshal(metadataRaw, &f.Metadata); err != nil {
-				return nil, fmt.Errorf("unmarshal metadata: %w", err)
-			}
-		}
-		flows = append(flows, f)
+		page.Items = append(page.Items, f)
+	}
+	if err := rows.Err(); err != nil {
+		return Page{}, err
 	}
 
-	return flows, rows.Err()
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		page.NextCursor = nextCursor(filter, page.Items[len(page.Items)-1])
+	}
+
+	return page, nil
 }
 
 func (r *repository) Get(ctx context.Context, id string) (Flow, error) {
-	const query = `SELECT id, name, description, definition, metadata, version, created_at, updated_at FROM flows WHERE id = $1`
+	query := `SELECT ` + flowColumns("definition") + ` FROM flows WHERE id = $1 AND tenant_id = $2`
 
-	var (
-		f           Flow
-		definition  []byte
-		metadataRaw []byte
-	)
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Flow{}, err
+	}
 
-	err := r.db.QueryRowxContext(ctx, query, id).Scan(&f.ID, &f.Name, &f.Description, &definition, &metadataRaw, &f.Version, &f.CreatedAt, &f.UpdatedAt)
+	f, err := scanFlow(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, tenantID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, persistence.ErrNotFound) {
 			return Flow{}, sqlErrNotFound
@@ -73,23 +81,16 @@ func (r *repository) Get(ctx context.Context, id string) (Flow, error) {
 		return Flow{}, fmt.Errorf("get flow: %w", err)
 	}
 
-	if len(definition) > 0 {
-		if err := json.Unmarshal(definition, &f.Definition); err != nil {
-			return Flow{}, fmt.Errorf("unmarshal definition: %w", err)
-		}
-	}
-
-	if len(metadataRaw) > 0 {
-		if err := json.Unmarshal(metadataRaw, &f.Metadata); err != nil {
-			return Flow{}, fmt.Errorf("unmarshal metadata: %w", err)
-		}
-	}
-
 	return f, nil
 }
 
 func (r *repository) Create(ctx context.Context, flow Flow) (Flow, error) {
-	const query = `INSERT INTO flows (id, name, description, definition, metadata, version, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+	const query = `INSERT INTO flows (id, tenant_id, name, description, definition, metadata, version, status, deploy_status, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Flow{}, err
+	}
 
 	definition, err := json.Marshal(flow.Definition)
 	if err != nil {
@@ -102,10 +103,11 @@ func (r *repository) Create(ctx context.Context, flow Flow) (Flow, error) {
 	}
 
 	now := time.Now().UTC()
+	flow.TenantID = tenantID
 	flow.CreatedAt = now
 	flow.UpdatedAt = now
 
-	_, err = r.db.ExecContext(ctx, query, flow.ID, flow.Name, flow.Description, definition, metadata, flow.Version, flow.CreatedAt, flow.UpdatedAt)
+	_, err = persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, flow.ID, flow.TenantID, flow.Name, flow.Description, definition, metadata, flow.Version, flow.Status, flow.DeployStatus, flow.CreatedAt, flow.UpdatedAt)
 	if err != nil {
 		return Flow{}, fmt.Errorf("insert flow: %w", err)
 	}
@@ -113,8 +115,13 @@ func (r *repository) Create(ctx context.Context, flow Flow) (Flow, error) {
 	return flow, nil
 }
 
-func (r *repository) Update(ctx context.Context, flow Flow) (Flow, error) {
-	const query = `UPDATE flows SET description = $2, definition = $3, metadata = $4, version = $5, updated_at = $6 WHERE id = $1`
+func (r *repository) Update(ctx context.Context, flow Flow, expectedVersion int) (Flow, error) {
+	const query = `UPDATE flows SET description = $2, definition = $3, metadata = $4, version = $5, updated_at = $6 WHERE id = $1 AND version = $7 AND tenant_id = $8`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Flow{}, err
+	}
 
 	definition, err := json.Marshal(flow.Definition)
 	if err != nil {
@@ -128,7 +135,7 @@ func (r *repository) Update(ctx context.Context, flow Flow) (Flow, error) {
 
 	flow.UpdatedAt = time.Now().UTC()
 
-	res, err := r.db.ExecContext(ctx, query, flow.ID, flow.Description, definition, metadata, flow.Version, flow.UpdatedAt)
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, flow.ID, flow.Description, definition, metadata, flow.Version, flow.UpdatedAt, expectedVersion, tenantID)
 	if err != nil {
 		return Flow{}, fmt.Errorf("update flow: %w", err)
 	}
@@ -139,8 +146,268 @@ func (r *repository) Update(ctx context.Context, flow Flow) (Flow, error) {
 	}
 
 	if affected == 0 {
+		var exists bool
+		if err := persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, `SELECT EXISTS (SELECT 1 FROM flows WHERE id = $1 AND tenant_id = $2)`, flow.ID, tenantID).Scan(&exists); err != nil {
+			return Flow{}, fmt.Errorf("check flow: %w", err)
+		}
+		if exists {
+			return Flow{}, sqlErrVersionConflict
+		}
 		return Flow{}, sqlErrNotFound
 	}
 
 	return flow, nil
 }
+
+func (r *repository) CreateVersion(ctx context.Context, snapshot Snapshot) error {
+	const query = `INSERT INTO flow_versions (flow_id, tenant_id, version, name, description, definition, metadata, created_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	definition, err := json.Marshal(snapshot.Definition)
+	if err != nil {
+		return fmt.Errorf("marshal definition: %w", err)
+	}
+
+	metadata, err := json.Marshal(snapshot.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+
+	_, err = persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, snapshot.FlowID, tenantID, snapshot.Version, snapshot.Name, snapshot.Description, definition, metadata, snapshot.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert flow version: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) ListVersions(ctx context.Context, flowID string) ([]Snapshot, error) {
+	const query = `SELECT ` + snapshotColumns + ` FROM flow_versions WHERE flow_id = $1 AND tenant_id = $2 ORDER BY version DESC`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, flowID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list flow versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []Snapshot
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, snapshot)
+	}
+
+	return versions, rows.Err()
+}
+
+func (r *repository) GetVersion(ctx context.Context, flowID string, version int) (Snapshot, error) {
+	const query = `SELECT ` + snapshotColumns + ` FROM flow_versions WHERE flow_id = $1 AND version = $2 AND tenant_id = $3`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot, err := scanSnapshot(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, flowID, version, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Snapshot{}, sqlErrNotFound
+		}
+		return Snapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+func (r *repository) LockDeployDue(ctx context.Context, now time.Time, limit int) ([]Flow, error) {
+	query := `SELECT ` + flowColumns("definition") + ` FROM flows
+WHERE deploy_status = $1 AND next_deploy_at <= $2
+ORDER BY next_deploy_at
+LIMIT $3
+FOR UPDATE SKIP LOCKED`
+
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, DeployPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("lock pending deployments: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Flow
+	for rows.Next() {
+		f, err := scanFlow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, f)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *repository) MarkDeployed(ctx context.Context, id string, version int, deployment Deployment, at time.Time) (Flow, error) {
+	query := `WITH deployed_version AS (
+	UPDATE flow_versions SET deployment_id = NULLIF($5, ''), process_definition_id = NULLIF($6, ''), published_at = COALESCE(published_at, $4)
+	WHERE flow_id = $1 AND version = $2 AND tenant_id = $7
+)
+UPDATE flows SET deploy_status = $3, deploy_attempts = deploy_attempts + 1, deploy_error = '', next_deploy_at = NULL, deployed_at = $4
+WHERE id = $1 AND published_version = $2 AND tenant_id = $7
+RETURNING ` + flowColumns("definition")
+
+	return r.updateDeploy(ctx, query, id, version, DeployDeployed, at, deployment.ID, deployment.ProcessDefinitionID)
+}
+
+func (r *repository) MarkDeployFailed(ctx context.Context, id string, version int, cause string, next time.Time, maxAttempts int) (Flow, error) {
+	query := `UPDATE flows SET
+	deploy_status = CASE WHEN deploy_attempts + 1 >= $5 THEN $6 ELSE $7 END,
+	next_deploy_at = CASE WHEN deploy_attempts + 1 >= $5 THEN NULL ELSE $4 END,
+	deploy_attempts = deploy_attempts + 1,
+	deploy_error = $3
+WHERE id = $1 AND published_version = $2 AND tenant_id = $8
+RETURNING ` + flowColumns("definition")
+
+	return r.updateDeploy(ctx, query, id, version, cause, next, maxAttempts, DeployFailed, DeployPending)
+}
+
+func (r *repository) ResetDeploy(ctx context.Context, id string, at time.Time) (Flow, error) {
+	query := `UPDATE flows SET deploy_status = $2, deploy_attempts = 0, deploy_error = '', next_deploy_at = $3
+WHERE id = $1 AND tenant_id = $4 AND published_version IS NOT NULL
+RETURNING ` + flowColumns("definition")
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Flow{}, err
+	}
+
+	f, err := scanFlow(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, DeployPending, at, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Flow{}, sqlErrNotFound
+		}
+		return Flow{}, fmt.Errorf("reset deployment: %w", err)
+	}
+	return f, nil
+}
+
+func (r *repository) Publish(ctx context.Context, id string, version int, at time.Time) (Flow, error) {
+	query := `UPDATE flows SET status = $3, published_version = $2, updated_at = $4,
+	deploy_status = $5, deploy_attempts = 0, deploy_error = '', next_deploy_at = $4
+WHERE id = $1 AND tenant_id = $6 AND (published_version IS NULL OR published_version < $2)
+RETURNING ` + flowColumns("definition")
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Flow{}, err
+	}
+
+	f, err := scanFlow(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, version, StatusPublished, at, DeployPending, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Flow{}, sqlErrStatusConflict
+		}
+		return Flow{}, fmt.Errorf("publish flow: %w", err)
+	}
+	return f, nil
+}
+
+func (r *repository) UpdateStatus(ctx context.Context, id string, from, to Status, at time.Time) (Flow, error) {
+	query := `UPDATE flows SET status = $3, updated_at = $4 WHERE id = $1 AND status = $2 AND tenant_id = $5
+RETURNING ` + flowColumns("definition")
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Flow{}, err
+	}
+
+	f, err := scanFlow(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, from, to, at, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Flow{}, sqlErrStatusConflict
+		}
+		return Flow{}, fmt.Errorf("update flow status: %w", err)
+	}
+	return f, nil
+}
+
+func (r *repository) updateDeploy(ctx context.Context, query, id string, version int, args ...any) (Flow, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Flow{}, err
+	}
+
+	args = append([]any{id, version}, append(args, tenantID)...)
+	f, err := scanFlow(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Flow{}, sqlErrVersionConflict
+		}
+		return Flow{}, fmt.Errorf("record deployment: %w", err)
+	}
+	return f, nil
+}
+
+func scanFlow(scanner interface {
+	Scan(dest ...any) error
+}) (Flow, error) {
+	var (
+		f           Flow
+		definition  []byte
+		metadataRaw []byte
+	)
+
+	if err := scanner.Scan(&f.ID, &f.TenantID, &f.Name, &f.Description, &definition, &metadataRaw, &f.Version, &f.Status, &f.PublishedVersion, &f.DeployStatus, &f.DeployAttempts, &f.DeployError, &f.NextDeployAt, &f.DeployedAt, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return Flow{}, err
+	}
+
+	if len(definition) > 0 {
+		if err := json.Unmarshal(definition, &f.Definition); err != nil {
+			return Flow{}, fmt.Errorf("unmarshal definition: %w", err)
+		}
+	}
+
+	if len(metadataRaw) > 0 {
+		if err := json.Unmarshal(metadataRaw, &f.Metadata); err != nil {
+			return Flow{}, fmt.Errorf("unmarshal metadata: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+func scanSnapshot(scanner interface {
+	Scan(dest ...any) error
+}) (Snapshot, error) {
+	var (
+		snapshot    Snapshot
+		definition  []byte
+		metadataRaw []byte
+	)
+
+	if err := scanner.Scan(&snapshot.FlowID, &snapshot.Version, &snapshot.Name, &snapshot.Description, &definition, &metadataRaw, &snapshot.DeploymentID, &snapshot.ProcessDefinitionID, &snapshot.PublishedAt, &snapshot.CreatedAt); err != nil {
+		return Snapshot{}, err
+	}
+
+	if len(definition) > 0 {
+		if err := json.Unmarshal(definition, &snapshot.Definition); err != nil {
+			return Snapshot{}, fmt.Errorf("unmarshal definition: %w", err)
+		}
+	}
+
+	if len(metadataRaw) > 0 {
+		if err := json.Unmarshal(metadataRaw, &snapshot.Metadata); err != nil {
+			return Snapshot{}, fmt.Errorf("unmarshal metadata: %w", err)
+		}
+	}
+
+	return snapshot, nil
+}
@@ -4,31 +4,50 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
 )
 
 type Service interface {
-	List(ctx context.Context) ([]Flow, error)
+	List(ctx context.Context, filter ListFilter) (Page, error)
 	Create(ctx context.Context, input CreateInput) (Flow, error)
 	Get(ctx context.Context, id string) (Flow, error)
 	Update(ctx context.Context, input UpdateInput) (Flow, error)
+	Publish(ctx context.Context, id string, version int) (Flow, error)
+	Deprecate(ctx context.Context, id string) (Flow, error)
+	Redeploy(ctx context.Context, id string) (Flow, error)
+	Validate(ctx context.Context, definition map[string]any) []bpmn.Problem
+	ListVersions(ctx context.Context, id string) ([]Snapshot, error)
+	GetVersion(ctx context.Context, id string, version int) (Snapshot, error)
+	DiffVersions(ctx context.Context, id string, from, to int) (VersionDiff, error)
 }
 
 type Repository interface {
-	List(ctx context.Context) ([]Flow, error)
+	List(ctx context.Context, filter ListFilter) (Page, error)
 	Get(ctx context.Context, id string) (Flow, error)
 	Create(ctx context.Context, flow Flow) (Flow, error)
-	Update(ctx context.Context, flow Flow) (Flow, error)
+	Update(ctx context.Context, flow Flow, expectedVersion int) (Flow, error)
+	CreateVersion(ctx context.Context, snapshot Snapshot) error
+	ListVersions(ctx context.Context, flowID string) ([]Snapshot, error)
+	Publish(ctx context.Context, id string, version int, at time.Time) (Flow, error)
+	UpdateStatus(ctx context.Context, id string, from, to Status, at time.Time) (Flow, error)
+	DeployRepository
 }
 
 type CamundaDeployer interface {
-	Deploy(ctx context.Context, flow Flow) error
+	Deploy(ctx context.Context, flow Flow, xml []byte) (Deployment, error)
 }
 
 type Publisher interface {
 	PublishFlowCreated(ctx context.Context, flow Flow) error
 	PublishFlowUpdated(ctx context.Context, flow Flow) error
+	PublishFlowPublished(ctx context.Context, flow Flow) error
+	PublishFlowDeprecated(ctx context.Context, flow Flow) error
 }
 
 type CreateInput struct {
@@ -39,16 +58,19 @@ type CreateInput struct {
 }
 
 type UpdateInput struct {
-	ID          string
-	Description string
-	Definition  map[string]any
-	Metadata    map[string]string
+	ID              string
+	ExpectedVersion int
+	Description     string
+	Definition      map[string]any
+	Metadata        map[string]string
 }
 
 type service struct {
 	repo      Repository
+	tx        persistence.Transactor
 	camunda   CamundaDeployer
 	publisher Publisher
+	deployer  deployer
 }
 
 type notFoundError struct{ id string }
@@ -57,17 +79,66 @@ func (e notFoundError) Error() string { return fmt.Sprintf("flow %s not found",
 
 func (notFoundError) NotFound() {}
 
+type versionNotFoundError struct {
+	id      string
+	version int
+}
+
+func (e versionNotFoundError) Error() string {
+	return fmt.Sprintf("flow %s version %d not found", e.id, e.version)
+}
+
+func (versionNotFoundError) NotFound() {}
+
+type ConflictError struct {
+	ID              string
+	ExpectedVersion int
+	CurrentVersion  int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("flow %s is at version %d, not %d", e.ID, e.CurrentVersion, e.ExpectedVersion)
+}
+
+func AsConflictError(err error) (*ConflictError, bool) {
+	var target *ConflictError
+	if errors.As(err, &target) {
+		return target, true
+	}
+	return nil, false
+}
+
 func IsNotFound(err error) bool {
-	var target notFoundError
+	var target interface{ NotFound() }
 	return errors.As(err, &target)
 }
 
-func NewService(repo Repository, camunda CamundaDeployer, publisher Publisher) Service {
-	return &service{repo: repo, camunda: camunda, publisher: publisher}
+type ValidationError struct {
+	Problems []bpmn.Problem
 }
 
-func (s *service) List(ctx context.Context) ([]Flow, error) {
-	return s.repo.List(ctx)
+func (e *ValidationError) Error() string {
+	return (&bpmn.CompileError{Problems: e.Problems}).Error()
+}
+
+func AsValidationError(err error) (*ValidationError, bool) {
+	var target *ValidationError
+	if errors.As(err, &target) {
+		return target, true
+	}
+	return nil, false
+}
+
+func NewService(repo Repository, tx persistence.Transactor, camunda CamundaDeployer, publisher Publisher, retry config.RetryConfig) Service {
+	return &service{repo: repo, tx: tx, camunda: camunda, publisher: publisher, deployer: deployer{repo: repo, camunda: camunda, retry: retry}}
+}
+
+func (s *service) List(ctx context.Context, filter ListFilter) (Page, error) {
+	filter, err := filter.normalize()
+	if err != nil {
+		return Page{}, err
+	}
+	return s.repo.List(ctx, filter)
 }
 
 func (s *service) Create(ctx context.Context, input CreateInput) (Flow, error) {
@@ -76,29 +147,41 @@ func (s *service) Create(ctx context.Context, input CreateInput) (Flow, error) {
 	}
 
 	flow := Flow{
-		ID:          uuid.NewString(),
-		Name:        input.Name,
-		Description: input.Description,
-		Definition:  input.Definition,
-		Metadata:    input.Metadata,
-		Version:     1,
+		ID:           uuid.NewString(),
+		Name:         input.Name,
+		Description:  input.Description,
+		Definition:   input.Definition,
+		Metadata:     input.Metadata,
+		Version:      1,
+		Status:       StatusDraft,
+		DeployStatus: DeployNone,
 	}
 
-	saved, err := s.repo.Create(ctx, flow)
-	if err != nil {
+	if err := validateDefinition(flow.Definition); err != nil {
 		return Flow{}, err
 	}
 
-	if s.camunda != nil {
-		if err := s.camunda.Deploy(ctx, saved); err != nil {
-			return Flow{}, fmt.Errorf("deploy to camunda: %w", err)
+	var saved Flow
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		saved, err = s.repo.Create(ctx, flow)
+		if err != nil {
+			return err
 		}
-	}
 
-	if s.publisher != nil {
-		if err := s.publisher.PublishFlowCreated(ctx, saved); err != nil {
-			return Flow{}, fmt.Errorf("publish flow created: %w", err)
+		if err := s.repo.CreateVersion(ctx, saved.Snapshot()); err != nil {
+			return err
 		}
+
+		if s.publisher != nil {
+			if err := s.publisher.PublishFlowCreated(ctx, saved); err != nil {
+				return fmt.Errorf("publish flow created: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Flow{}, err
 	}
 
 	return saved, nil
@@ -124,32 +207,139 @@ func (s *service) Update(ctx context.Context, input UpdateInput) (Flow, error) {
 		return Flow{}, err
 	}
 
+	if existing.Version != input.ExpectedVersion {
+		return Flow{}, &ConflictError{ID: existing.ID, ExpectedVersion: input.ExpectedVersion, CurrentVersion: existing.Version}
+	}
+
 	existing.Description = input.Description
 	existing.Definition = input.Definition
 	existing.Metadata = input.Metadata
 	existing.Version++
 
-	saved, err := s.repo.Update(ctx, existing)
+	if err := validateDefinition(existing.Definition); err != nil {
+		return Flow{}, err
+	}
+
+	var saved Flow
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		saved, err = s.repo.Update(ctx, existing, input.ExpectedVersion)
+		if err != nil {
+			if errors.Is(err, sqlErrVersionConflict) {
+				return s.conflict(ctx, input.ID, input.ExpectedVersion)
+			}
+			if errors.Is(err, sqlErrNotFound) {
+				return notFoundError{id: input.ID}
+			}
+			return err
+		}
+
+		if err := s.repo.CreateVersion(ctx, saved.Snapshot()); err != nil {
+			return err
+		}
+
+		if s.publisher != nil {
+			if err := s.publisher.PublishFlowUpdated(ctx, saved); err != nil {
+				return fmt.Errorf("publish flow updated: %w", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return Flow{}, err
 	}
 
-	if s.camunda != nil {
-		if err := s.camunda.Deploy(ctx, saved); err != nil {
-			return Flow{}, fmt.Errorf("deploy to camunda: %w", err)
+	return saved, nil
+}
+
+func (s *service) Redeploy(ctx context.Context, id string) (Flow, error) {
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return Flow{}, err
+	}
+	if current.PublishedVersion == 0 {
+		return Flow{}, &LifecycleError{ID: id, Status: current.Status, Action: "redeployed"}
+	}
+	if s.camunda == nil {
+		return current, nil
+	}
+
+	pending, err := s.repo.ResetDeploy(ctx, id, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Flow{}, notFoundError{id: id}
 		}
+		return Flow{}, err
+	}
+
+	deployed, err := s.deployer.deploy(ctx, pending, nil)
+	if err != nil {
+		return Flow{}, err
+	}
+	return deployed, nil
+}
+
+func (s *service) withinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.tx == nil {
+		return fn(ctx)
+	}
+	return s.tx.WithinTransaction(ctx, fn)
+}
+
+func (s *service) conflict(ctx context.Context, id string, expected int) error {
+	current, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("load current flow: %w", err)
 	}
+	return &ConflictError{ID: id, ExpectedVersion: expected, CurrentVersion: current.Version}
+}
 
-	if s.publisher != nil {
-		if err := s.publisher.PublishFlowUpdated(ctx, saved); err != nil {
-			return Flow{}, fmt.Errorf("publish flow updated: %w", err)
+func (s *service) ListVersions(ctx context.Context, id string) ([]Snapshot, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListVersions(ctx, id)
+}
+
+func (s *service) GetVersion(ctx context.Context, id string, version int) (Snapshot, error) {
+	snapshot, err := s.repo.GetVersion(ctx, id, version)
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Snapshot{}, versionNotFoundError{id: id, version: version}
 		}
+		return Snapshot{}, err
 	}
+	return snapshot, nil
+}
 
-	return saved, nil
+func (s *service) DiffVersions(ctx context.Context, id string, from, to int) (VersionDiff, error) {
+	before, err := s.GetVersion(ctx, id, from)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+	after, err := s.GetVersion(ctx, id, to)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+	return Diff(before, after), nil
+}
+
+func (s *service) Validate(_ context.Context, definition map[string]any) []bpmn.Problem {
+	return bpmn.Validate(definition)
 }
 
-var sqlErrNotFound = errors.New("flow not found")
+func validateDefinition(definition map[string]any) error {
+	if problems := bpmn.Validate(definition); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+var (
+	sqlErrNotFound        = errors.New("flow not found")
+	sqlErrVersionConflict = errors.New("flow version conflict")
+	sqlErrStatusConflict  = errors.New("flow status changed concurrently")
+)
 
 func WrapNotFound(err error) error {
 	if err == nil {
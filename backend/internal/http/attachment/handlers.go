@@ -0,0 +1,116 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/attachment"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+const multipartOverhead = 1 << 20
+
+type Handlers struct {
+	Service attachment.Service
+	MaxSize int64
+}
+
+func (h Handlers) Upload(c *gin.Context) {
+	if h.MaxSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MaxSize+multipartOverhead)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(c, &attachment.LimitError{Size: c.Request.ContentLength, Max: h.MaxSize})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("multipart field \"file\" is required: %v", err)})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	item, err := h.Service.Upload(c.Request.Context(), c.Param("id"), attachment.Upload{
+		FileName:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		Size:        header.Size,
+		Checksum:    c.PostForm("checksum"),
+		Content:     file,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h Handlers) List(c *gin.Context) {
+	items, err := h.Service.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h Handlers) Get(c *gin.Context) {
+	item, err := h.Service.Get(c.Request.Context(), c.Param("id"), c.Param("attachmentId"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Download(c *gin.Context) {
+	item, blob, err := h.Service.Open(c.Request.Context(), c.Param("id"), c.Param("attachmentId"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Type", item.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": item.FileName}))
+	c.Header("ETag", `"`+item.Checksum+`"`)
+	c.Header("X-Checksum-SHA256", item.Checksum)
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, item.FileName, item.CreatedAt, blob)
+}
+
+func (h Handlers) Delete(c *gin.Context) {
+	if err := h.Service.Delete(c.Request.Context(), c.Param("id"), c.Param("attachmentId")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case attachment.IsNotFound(err), workorder.IsNotFound(err):
+		status = http.StatusNotFound
+	case attachment.IsInvalidUpload(err):
+		status = http.StatusBadRequest
+	case attachment.IsTooLarge(err):
+		status = http.StatusRequestEntityTooLarge
+	case attachment.IsUnsupportedType(err):
+		status = http.StatusUnsupportedMediaType
+	case attachment.IsChecksumMismatch(err):
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/auth"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+func authenticate(authenticator auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			if !auth.IsUnauthenticated(err) {
+				log.Printf("authenticate: %v", err)
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="pflow"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+
+		tenantID, status, err := resolveTenant(c.Request, principal)
+		if err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := auth.WithPrincipal(c.Request.Context(), principal)
+		ctx = tenant.WithTenant(ctx, tenantID)
+		ctx = workorder.WithActor(ctx, principal.Subject)
+		ctx = workorder.WithGroups(ctx, principal.Groups)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func resolveTenant(r *http.Request, principal auth.Principal) (string, int, error) {
+	requested := r.Header.Get(tenant.Header)
+	if requested != "" && !tenant.Valid(requested) {
+		return "", http.StatusBadRequest, fmt.Errorf("invalid %s header", tenant.Header)
+	}
+
+	switch {
+	case requested != "":
+		if !principal.CanAccessTenant(requested) {
+			return "", http.StatusForbidden, fmt.Errorf("principal is not a member of tenant %s", requested)
+		}
+		return requested, 0, nil
+	case principal.Tenant != "":
+		return principal.Tenant, 0, nil
+	case principal.MultiTenant():
+		return tenant.DefaultID, 0, nil
+	default:
+		return "", http.StatusForbidden, fmt.Errorf("principal is not bound to a tenant; set %s to one of its tenants", tenant.Header)
+	}
+}
+
+func requireRole(roles ...auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		if !principal.HasAnyRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role", "required": roles})
+			return
+		}
+		c.Next()
+	}
+}
+
+func currentPrincipal(c *gin.Context) {
+	principal, _ := auth.PrincipalFromContext(c.Request.Context())
+	tenantID, _ := tenant.FromContext(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{"principal": principal, "tenant": tenantID})
+}
@@ -0,0 +1,127 @@
+package bulk
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bulk"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Handlers struct {
+	Service bulk.Service
+}
+
+type batchItem struct {
+	FlowID          string            `json:"flowId" binding:"required"`
+	ExternalID      string            `json:"externalId"`
+	IdempotencyKey  string            `json:"idempotencyKey"`
+	Title           string            `json:"title" binding:"required"`
+	Priority        string            `json:"priority"`
+	Assignee        string            `json:"assignee"`
+	CandidateUsers  []string          `json:"candidateUsers"`
+	CandidateGroups []string          `json:"candidateGroups"`
+	Payload         map[string]any    `json:"payload"`
+	Metadata        map[string]string `json:"metadata"`
+}
+
+type batchRequest struct {
+	Items []batchItem `json:"items" binding:"required,dive"`
+}
+
+type actionRequest struct {
+	Action   bulk.Action        `json:"action" binding:"required"`
+	IDs      []string           `json:"ids"`
+	Filter   *bulk.ActionFilter `json:"filter"`
+	Reason   string             `json:"reason"`
+	Assignee string             `json:"assignee"`
+}
+
+func (h Handlers) Dispatch(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("action"), ":") {
+	case "batch":
+		h.Batch(c)
+	case "bulk-action":
+		h.BulkAction(c)
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown workorder action"})
+	}
+}
+
+func (h Handlers) Batch(c *gin.Context) {
+	var req batchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inputs := make([]workorder.CreateInput, len(req.Items))
+	for i, item := range req.Items {
+		inputs[i] = workorder.CreateInput{
+			FlowID:         item.FlowID,
+			ExternalID:     item.ExternalID,
+			IdempotencyKey: item.IdempotencyKey,
+			Title:          item.Title,
+			Priority:       workorder.Priority(item.Priority),
+			Assignee:       item.Assignee,
+			Candidates:     workorder.Candidates{Users: item.CandidateUsers, Groups: item.CandidateGroups},
+			Payload:        item.Payload,
+			Metadata:       item.Metadata,
+		}
+	}
+
+	job, err := h.Service.CreateBatch(c.Request.Context(), inputs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	accepted(c, job)
+}
+
+func (h Handlers) BulkAction(c *gin.Context) {
+	var req actionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.Service.Submit(c.Request.Context(), bulk.ActionInput{
+		Action:   req.Action,
+		IDs:      req.IDs,
+		Filter:   req.Filter,
+		Reason:   req.Reason,
+		Assignee: req.Assignee,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	accepted(c, job)
+}
+
+func (h Handlers) Get(c *gin.Context) {
+	job, err := h.Service.Get(c.Request.Context(), c.Param("id"), bulk.ItemStatus(c.Query("itemStatus")))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func accepted(c *gin.Context, job bulk.Job) {
+	c.Header("Location", "/api/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+func writeError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case bulk.IsNotFound(err):
+		status = http.StatusNotFound
+	case bulk.IsInvalidInput(err), workorder.IsInvalidQuery(err):
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
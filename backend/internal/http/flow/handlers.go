@@ -1,10 +1,14 @@
 package flow
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
 )
 
@@ -20,18 +24,43 @@ type createFlowRequest struct {
 }
 
 type updateFlowRequest struct {
+	Version     *int              `json:"version"`
 	Description string            `json:"description"`
 	Definition  map[string]any    `json:"definition" binding:"required"`
 	Metadata    map[string]string `json:"metadata"`
 }
 
+type publishFlowRequest struct {
+	Version int `json:"version"`
+}
+
+type validateFlowRequest struct {
+	Definition map[string]any `json:"definition" binding:"required"`
+}
+
 func (h Handlers) List(c *gin.Context) {
-	flows, err := h.Service.List(c.Request.Context())
+	filter, err := parseListFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, flows)
+
+	page, err := h.Service.List(c.Request.Context(), filter)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if filter.IncludeDefinition {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	summaries := make([]flow.Summary, 0, len(page.Items))
+	for _, f := range page.Items {
+		summaries = append(summaries, f.Summary())
+	}
+	c.JSON(http.StatusOK, gin.H{"items": summaries, "nextCursor": page.NextCursor})
 }
 
 func (h Handlers) Create(c *gin.Context) {
@@ -48,22 +77,20 @@ func (h Handlers) Create(c *gin.Context) {
 		Metadata:    req.Metadata,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
+	setETag(c, created.Version)
 	c.JSON(http.StatusCreated, created)
 }
 
 func (h Handlers) Get(c *gin.Context) {
 	result, err := h.Service.Get(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		status := http.StatusInternalServerError
-		if flow.IsNotFound(err) {
-			status = http.StatusNotFound
-		}
-		c.JSON(status, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
+	setETag(c, result.Version)
 	c.JSON(http.StatusOK, result)
 }
 
@@ -74,19 +101,234 @@ func (h Handlers) Update(c *gin.Context) {
 		return
 	}
 
+	expected, ok := expectedVersion(c, req.Version)
+	if !ok {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "an If-Match header or version field with the expected flow version is required"})
+		return
+	}
+
 	updated, err := h.Service.Update(c.Request.Context(), flow.UpdateInput{
-		ID:          c.Param("id"),
-		Description: req.Description,
-		Definition:  req.Definition,
-		Metadata:    req.Metadata,
+		ID:              c.Param("id"),
+		ExpectedVersion: expected,
+		Description:     req.Description,
+		Definition:      req.Definition,
+		Metadata:        req.Metadata,
 	})
 	if err != nil {
-		status := http.StatusInternalServerError
-		if flow.IsNotFound(err) {
-			status = http.StatusNotFound
-		}
-		c.JSON(status, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
+	setETag(c, updated.Version)
 	c.JSON(http.StatusOK, updated)
 }
+
+func (h Handlers) Publish(c *gin.Context) {
+	var req publishFlowRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Version < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a positive integer"})
+		return
+	}
+
+	published, err := h.Service.Publish(c.Request.Context(), c.Param("id"), req.Version)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	setETag(c, published.Version)
+	c.JSON(http.StatusOK, published)
+}
+
+func (h Handlers) Deprecate(c *gin.Context) {
+	deprecated, err := h.Service.Deprecate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	setETag(c, deprecated.Version)
+	c.JSON(http.StatusOK, deprecated)
+}
+
+func (h Handlers) Redeploy(c *gin.Context) {
+	deployed, err := h.Service.Redeploy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	setETag(c, deployed.Version)
+	c.JSON(http.StatusOK, deployed)
+}
+
+func (h Handlers) Validate(c *gin.Context) {
+	var req validateFlowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problems := h.Service.Validate(c.Request.Context(), req.Definition)
+	if problems == nil {
+		problems = []bpmn.Problem{}
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": len(problems) == 0, "problems": problems})
+}
+
+func (h Handlers) ListVersions(c *gin.Context) {
+	versions, err := h.Service.ListVersions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+func (h Handlers) GetVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version must be an integer"})
+		return
+	}
+
+	snapshot, err := h.Service.GetVersion(c.Request.Context(), c.Param("id"), version)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+func (h Handlers) Diff(c *gin.Context) {
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an integer"})
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an integer"})
+		return
+	}
+
+	diff, err := h.Service.DiffVersions(c.Request.Context(), c.Param("id"), from, to)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+func parseListFilter(c *gin.Context) (flow.ListFilter, error) {
+	filter := flow.ListFilter{
+		Search:   c.Query("q"),
+		Metadata: c.QueryMap("metadata"),
+		Sort:     flow.SortField(c.Query("sort")),
+		Cursor:   c.Query("cursor"),
+	}
+
+	for _, status := range queryList(c, "status") {
+		filter.Statuses = append(filter.Statuses, flow.Status(status))
+	}
+	for _, status := range queryList(c, "deployStatus") {
+		filter.DeployStatuses = append(filter.DeployStatuses, flow.DeployStatus(status))
+	}
+
+	for _, raw := range c.QueryArray("include") {
+		for _, include := range strings.Split(raw, ",") {
+			switch strings.TrimSpace(include) {
+			case "definition":
+				filter.IncludeDefinition = true
+			case "":
+			default:
+				return flow.ListFilter{}, fmt.Errorf("unsupported include %q", include)
+			}
+		}
+	}
+
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "asc":
+		filter.Ascending = true
+	case "desc":
+	default:
+		return flow.ListFilter{}, fmt.Errorf("order must be asc or desc, got %q", order)
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return flow.ListFilter{}, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+func queryList(c *gin.Context, param string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(param) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+func expectedVersion(c *gin.Context, bodyVersion *int) (int, bool) {
+	if match := strings.TrimSpace(c.GetHeader("If-Match")); match != "" {
+		match = strings.TrimPrefix(match, "W/")
+		if unquoted, err := strconv.Unquote(match); err == nil {
+			match = unquoted
+		}
+		version, err := strconv.Atoi(match)
+		if err != nil {
+			return 0, false
+		}
+		return version, true
+	}
+	if bodyVersion != nil {
+		return *bodyVersion, true
+	}
+	return 0, false
+}
+
+func writeError(c *gin.Context, err error) {
+	if conflictErr, ok := flow.AsConflictError(err); ok {
+		setETag(c, conflictErr.CurrentVersion)
+		c.JSON(http.StatusConflict, gin.H{"error": conflictErr.Error(), "currentVersion": conflictErr.CurrentVersion})
+		return
+	}
+	if validationErr, ok := flow.AsValidationError(err); ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": validationErr.Error(), "problems": validationErr.Problems})
+		return
+	}
+	if compileErr, ok := bpmn.AsCompileError(err); ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": compileErr.Error(), "problems": compileErr.Problems})
+		return
+	}
+	if deployErr, ok := flow.AsDeployError(err); ok {
+		c.JSON(http.StatusBadGateway, gin.H{"error": deployErr.Error(), "flow": deployErr.Flow})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case flow.IsNotFound(err):
+		status = http.StatusNotFound
+	case flow.IsInvalidQuery(err):
+		status = http.StatusBadRequest
+	case flow.IsLifecycleConflict(err):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,99 @@
+package migration
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/migration"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Handlers struct {
+	Service migration.Service
+}
+
+type planRequest struct {
+	SourceVersion int               `json:"sourceVersion" binding:"required"`
+	TargetVersion int               `json:"targetVersion" binding:"required"`
+	Overrides     map[string]string `json:"overrides"`
+	WorkOrderIDs  []string          `json:"workOrderIds"`
+}
+
+func (r planRequest) input(flowID string) migration.PlanInput {
+	return migration.PlanInput{
+		FlowID:        flowID,
+		SourceVersion: r.SourceVersion,
+		TargetVersion: r.TargetVersion,
+		Overrides:     r.Overrides,
+		WorkOrderIDs:  r.WorkOrderIDs,
+	}
+}
+
+func (h Handlers) Preview(c *gin.Context) {
+	var req planRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.Service.Preview(c.Request.Context(), req.input(c.Param("id")))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+func (h Handlers) Start(c *gin.Context) {
+	var req planRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	started, err := h.Service.Start(c.Request.Context(), req.input(c.Param("id")))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("Location", "/api/flows/"+started.FlowID+"/migrations/"+started.ID)
+	c.JSON(http.StatusAccepted, started)
+}
+
+func (h Handlers) List(c *gin.Context) {
+	items, err := h.Service.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h Handlers) Get(c *gin.Context) {
+	item, err := h.Service.Get(c.Request.Context(), c.Param("id"), c.Param("migrationId"), migration.ItemStatus(c.Query("itemStatus")))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func writeError(c *gin.Context, err error) {
+	if planErr, ok := migration.AsPlanError(err); ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "problems": planErr.Problems})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case migration.IsNotFound(err), flow.IsNotFound(err):
+		status = http.StatusNotFound
+	case migration.IsInvalidInput(err), workorder.IsInvalidQuery(err):
+		status = http.StatusBadRequest
+	case migration.IsVersionUnavailable(err):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
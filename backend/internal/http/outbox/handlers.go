@@ -0,0 +1,27 @@
+package outbox
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/outbox"
+)
+
+type StatsReader interface {
+	Stats(ctx context.Context) (outbox.Stats, error)
+}
+
+type Handlers struct {
+	Service StatsReader
+}
+
+func (h Handlers) Stats(c *gin.Context) {
+	stats, err := h.Service.Stats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
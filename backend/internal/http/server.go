@@ -4,12 +4,18 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/kyeliu99/Pflow_v2/backend/internal/auth"
 	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	attachmenthttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/attachment"
+	bulkhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/bulk"
 	flowhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/flow"
+	migrationhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/migration"
+	outboxhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/outbox"
+	slahttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/sla"
+	taskhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/task"
 	workorderhttp "github.com/kyeliu99/Pflow_v2/backend/internal/http/workorder"
 )
 
@@ -18,24 +24,84 @@ type Server struct {
 	http   *http.Server
 }
 
-func NewServer(cfg config.Config, flowHandlers flowhttp.Handlers, workorderHandlers workorderhttp.Handlers) *Server {
+func NewServer(cfg config.Config, authenticator auth.Authenticator, flowHandlers flowhttp.Handlers, migrationHandlers migrationhttp.Handlers, workorderHandlers workorderhttp.Handlers, attachmentHandlers attachmenthttp.Handlers, bulkHandlers bulkhttp.Handlers, taskHandlers taskhttp.Handlers, slaHandlers slahttp.Handlers, outboxHandlers outboxhttp.Handlers) *Server {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
 	engine.Use(gin.Logger(), gin.Recovery())
 
-	api := engine.Group("/api")
+	if authenticator == nil {
+		authenticator = auth.Anonymous()
+	}
+
+	viewer := requireRole(auth.RoleViewer)
+	designer := requireRole(auth.RoleFlowDesigner)
+	operator := requireRole(auth.RoleOperator)
+	admin := requireRole(auth.RoleAdmin)
+
+	api := engine.Group("/api", authenticate(authenticator))
 	{
+		api.GET("/me", currentPrincipal)
+
 		flow := api.Group("/flows")
-		flow.GET("", flowHandlers.List)
-		flow.POST("", flowHandlers.Create)
-		flow.GET(":id", flowHandlers.Get)
-		flow.PUT(":id", flowHandlers.Update)
+		flow.GET("", viewer, flowHandlers.List)
+		flow.POST("", designer, flowHandlers.Create)
+		flow.POST("validate", designer, flowHandlers.Validate)
+		flow.GET(":id", viewer, flowHandlers.Get)
+		flow.PUT(":id", designer, flowHandlers.Update)
+		flow.POST(":id/publish", designer, flowHandlers.Publish)
+		flow.POST(":id/deprecate", designer, flowHandlers.Deprecate)
+		flow.POST(":id/deploy", designer, flowHandlers.Redeploy)
+		flow.GET(":id/versions", viewer, flowHandlers.ListVersions)
+		flow.GET(":id/versions/:version", viewer, flowHandlers.GetVersion)
+		flow.GET(":id/diff", viewer, flowHandlers.Diff)
+		flow.POST(":id/migrations/preview", designer, migrationHandlers.Preview)
+		flow.POST(":id/migrations", designer, migrationHandlers.Start)
+		flow.GET(":id/migrations", viewer, migrationHandlers.List)
+		flow.GET(":id/migrations/:migrationId", viewer, migrationHandlers.Get)
 
 		workorders := api.Group("/workorders")
-		workorders.GET("", workorderHandlers.List)
-		workorders.POST("", workorderHandlers.Create)
-		workorders.GET(":id", workorderHandlers.Get)
-		workorders.POST(":id/retry", workorderHandlers.Retry)
+		workorders.GET("", viewer, workorderHandlers.List)
+		workorders.POST("", operator, workorderHandlers.Create)
+		workorders.GET(":id", viewer, workorderHandlers.Get)
+		workorders.POST(":id/retry", operator, workorderHandlers.Retry)
+		workorders.POST(":id/cancel", operator, workorderHandlers.Cancel)
+		workorders.POST(":id/suspend", operator, workorderHandlers.Suspend)
+		workorders.POST(":id/resume", operator, workorderHandlers.Resume)
+		workorders.GET(":id/process", viewer, workorderHandlers.Process)
+		workorders.GET(":id/transitions", viewer, workorderHandlers.Transitions)
+		workorders.POST(":id/assign", operator, workorderHandlers.Assign)
+		workorders.POST(":id/claim", operator, workorderHandlers.Claim)
+		workorders.POST(":id/release", operator, workorderHandlers.Release)
+		workorders.PUT(":id/candidates", operator, workorderHandlers.SetCandidates)
+		workorders.GET(":id/assignments", viewer, workorderHandlers.Assignments)
+		workorders.GET(":id/comments", viewer, workorderHandlers.Comments)
+		workorders.POST(":id/comments", operator, workorderHandlers.AddComment)
+		workorders.PUT(":id/comments/:commentId", operator, workorderHandlers.EditComment)
+		workorders.DELETE(":id/comments/:commentId", operator, workorderHandlers.DeleteComment)
+		workorders.GET(":id/timeline", viewer, workorderHandlers.Timeline)
+		workorders.GET(":id/attachments", viewer, attachmentHandlers.List)
+		workorders.POST(":id/attachments", operator, attachmentHandlers.Upload)
+		workorders.GET(":id/attachments/:attachmentId", viewer, attachmentHandlers.Get)
+		workorders.GET(":id/attachments/:attachmentId/content", viewer, attachmentHandlers.Download)
+		workorders.DELETE(":id/attachments/:attachmentId", operator, attachmentHandlers.Delete)
+
+		api.POST("/workorders:action", operator, bulkHandlers.Dispatch)
+		api.GET("/jobs/:id", viewer, bulkHandlers.Get)
+
+		tasks := api.Group("/tasks")
+		tasks.GET("", viewer, taskHandlers.List)
+		tasks.GET(":id", viewer, taskHandlers.Get)
+		tasks.POST(":id/claim", operator, taskHandlers.Claim)
+		tasks.POST(":id/complete", operator, taskHandlers.Complete)
+
+		policies := api.Group("/sla-policies")
+		policies.GET("", viewer, slaHandlers.List)
+		policies.POST("", admin, slaHandlers.Create)
+		policies.GET(":id", viewer, slaHandlers.Get)
+		policies.PUT(":id", admin, slaHandlers.Update)
+		policies.DELETE(":id", admin, slaHandlers.Delete)
+
+		api.GET("/outbox/stats", admin, outboxHandlers.Stats)
 	}
 
 	httpServer := &http.Server{
@@ -0,0 +1,101 @@
+package sla
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/sla"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Handlers struct {
+	Service sla.Service
+}
+
+type policyRequest struct {
+	FlowID     string       `json:"flowId"`
+	Priority   string       `json:"priority"`
+	Resolution sla.Duration `json:"resolution" binding:"required"`
+	Warning    sla.Duration `json:"warning"`
+}
+
+func (r policyRequest) input() sla.PolicyInput {
+	return sla.PolicyInput{
+		FlowID:     r.FlowID,
+		Priority:   workorder.Priority(r.Priority),
+		Resolution: time.Duration(r.Resolution),
+		Warning:    time.Duration(r.Warning),
+	}
+}
+
+func (h Handlers) List(c *gin.Context) {
+	items, err := h.Service.List(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h Handlers) Create(c *gin.Context) {
+	var req policyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.Create(c.Request.Context(), req.input())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h Handlers) Get(c *gin.Context) {
+	item, err := h.Service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Update(c *gin.Context) {
+	var req policyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.Update(c.Request.Context(), c.Param("id"), req.input())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Delete(c *gin.Context) {
+	if err := h.Service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case sla.IsNotFound(err), flow.IsNotFound(err):
+		status = http.StatusNotFound
+	case sla.IsInvalid(err):
+		status = http.StatusBadRequest
+	case sla.IsConflict(err):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
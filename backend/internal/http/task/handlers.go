@@ -0,0 +1,113 @@
+package task
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/task"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Handlers struct {
+	Service task.Service
+}
+
+type completeRequest struct {
+	Variables map[string]any `json:"variables"`
+}
+
+func (h Handlers) List(c *gin.Context) {
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.Service.List(c.Request.Context(), filter)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h Handlers) Get(c *gin.Context) {
+	item, err := h.Service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Claim(c *gin.Context) {
+	item, err := h.Service.Claim(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Complete(c *gin.Context) {
+	var req completeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.Service.Complete(c.Request.Context(), c.Param("id"), req.Variables)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func parseListFilter(c *gin.Context) (task.ListFilter, error) {
+	filter := task.ListFilter{
+		Scope:    task.Scope(c.Query("scope")),
+		Assignee: c.Query("assignee"),
+		Group:    c.Query("group"),
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return task.ListFilter{}, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return task.ListFilter{}, fmt.Errorf("offset must be a non-negative integer")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
+
+func writeError(c *gin.Context, err error) {
+	if formErr, ok := task.AsFormError(err); ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": formErr.Error(), "fields": formErr.Problems})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case task.IsNotFound(err), workorder.IsNotFound(err):
+		status = http.StatusNotFound
+	case task.IsInvalidQuery(err):
+		status = http.StatusBadRequest
+	case task.IsNotAssignee(err), workorder.IsNotCandidate(err):
+		status = http.StatusForbidden
+	case workorder.IsAssignmentConflict(err), workorder.IsInvalidTransition(err), workorder.IsTerminalState(err):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
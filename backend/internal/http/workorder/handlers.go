@@ -1,7 +1,12 @@
 package workorder
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -12,21 +17,46 @@ type Handlers struct {
 	Service workorder.Service
 }
 
+const idempotencyKeyHeader = "Idempotency-Key"
+
 type createRequest struct {
-	FlowID   string            `json:"flowId" binding:"required"`
-	Title    string            `json:"title" binding:"required"`
-	Assignee string            `json:"assignee"`
-	Payload  map[string]any    `json:"payload"`
-	Metadata map[string]string `json:"metadata"`
+	FlowID          string            `json:"flowId" binding:"required"`
+	ExternalID      string            `json:"externalId"`
+	Title           string            `json:"title" binding:"required"`
+	Priority        string            `json:"priority"`
+	Assignee        string            `json:"assignee"`
+	CandidateUsers  []string          `json:"candidateUsers"`
+	CandidateGroups []string          `json:"candidateGroups"`
+	Payload         map[string]any    `json:"payload"`
+	Metadata        map[string]string `json:"metadata"`
+}
+
+type assignRequest struct {
+	Assignee string `json:"assignee"`
+	Reason   string `json:"reason"`
+}
+
+type commentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type reasonRequest struct {
+	Reason string `json:"reason"`
 }
 
 func (h Handlers) List(c *gin.Context) {
-	items, err := h.Service.List(c.Request.Context())
+	filter, err := parseListFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, items)
+
+	page, err := h.Service.List(c.Request.Context(), filter)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 func (h Handlers) Create(c *gin.Context) {
@@ -36,15 +66,30 @@ func (h Handlers) Create(c *gin.Context) {
 		return
 	}
 
-	item, err := h.Service.Create(c.Request.Context(), workorder.CreateInput{
-		FlowID:   req.FlowID,
-		Title:    req.Title,
-		Assignee: req.Assignee,
-		Payload:  req.Payload,
-		Metadata: req.Metadata,
+	key := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+	if len(key) > workorder.MaxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s exceeds %d characters", idempotencyKeyHeader, workorder.MaxIdempotencyKeyLength)})
+		return
+	}
+
+	item, replayed, err := h.Service.Create(c.Request.Context(), workorder.CreateInput{
+		FlowID:         req.FlowID,
+		ExternalID:     req.ExternalID,
+		IdempotencyKey: key,
+		Title:          req.Title,
+		Priority:       workorder.Priority(req.Priority),
+		Assignee:       req.Assignee,
+		Candidates:     workorder.Candidates{Users: req.CandidateUsers, Groups: req.CandidateGroups},
+		Payload:        req.Payload,
+		Metadata:       req.Metadata,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
+		return
+	}
+	if replayed {
+		c.Header("Idempotent-Replayed", "true")
+		c.JSON(http.StatusOK, item)
 		return
 	}
 	c.JSON(http.StatusCreated, item)
@@ -53,11 +98,7 @@ func (h Handlers) Create(c *gin.Context) {
 func (h Handlers) Get(c *gin.Context) {
 	item, err := h.Service.Get(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		status := http.StatusInternalServerError
-		if workorder.IsNotFound(err) {
-			status = http.StatusNotFound
-		}
-		c.JSON(status, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, item)
@@ -65,12 +106,289 @@ func (h Handlers) Get(c *gin.Context) {
 
 func (h Handlers) Retry(c *gin.Context) {
 	if err := h.Service.Retry(c.Request.Context(), c.Param("id")); err != nil {
-		status := http.StatusInternalServerError
-		if workorder.IsNotFound(err) {
-			status = http.StatusNotFound
-		}
-		c.JSON(status, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 	c.Status(http.StatusAccepted)
 }
+
+func (h Handlers) Cancel(c *gin.Context) {
+	h.changeState(c, h.Service.Cancel)
+}
+
+func (h Handlers) Suspend(c *gin.Context) {
+	h.changeState(c, h.Service.Suspend)
+}
+
+func (h Handlers) Resume(c *gin.Context) {
+	h.changeState(c, h.Service.Resume)
+}
+
+func (h Handlers) changeState(c *gin.Context, change func(ctx context.Context, id, reason string) (workorder.WorkOrder, error)) {
+	var req reasonRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	item, err := change(c.Request.Context(), c.Param("id"), req.Reason)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Process(c *gin.Context) {
+	state, err := h.Service.Process(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+func (h Handlers) Transitions(c *gin.Context) {
+	items, err := h.Service.Transitions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h Handlers) Assign(c *gin.Context) {
+	var req assignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.Assign(c.Request.Context(), c.Param("id"), req.Assignee, req.Reason)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Claim(c *gin.Context) {
+	item, err := h.Service.Claim(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Release(c *gin.Context) {
+	var req reasonRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	item, err := h.Service.Release(c.Request.Context(), c.Param("id"), req.Reason)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) SetCandidates(c *gin.Context) {
+	var req workorder.Candidates
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.SetCandidates(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) Assignments(c *gin.Context) {
+	items, err := h.Service.Assignments(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h Handlers) Comments(c *gin.Context) {
+	items, err := h.Service.Comments(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h Handlers) AddComment(c *gin.Context) {
+	var req commentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.AddComment(c.Request.Context(), c.Param("id"), req.Body)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h Handlers) EditComment(c *gin.Context) {
+	var req commentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.EditComment(c.Request.Context(), c.Param("id"), c.Param("commentId"), req.Body)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h Handlers) DeleteComment(c *gin.Context) {
+	if err := h.Service.DeleteComment(c.Request.Context(), c.Param("id"), c.Param("commentId")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h Handlers) Timeline(c *gin.Context) {
+	var descending bool
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		descending = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+
+	items, err := h.Service.Timeline(c.Request.Context(), c.Param("id"), descending)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func parseListFilter(c *gin.Context) (workorder.ListFilter, error) {
+	filter := workorder.ListFilter{
+		FlowID:   c.Query("flowId"),
+		Assignee: c.Query("assignee"),
+		Metadata: c.QueryMap("metadata"),
+		Sort:     workorder.SortField(c.Query("sort")),
+		Cursor:   c.Query("cursor"),
+	}
+
+	for _, status := range queryList(c, "status") {
+		filter.Statuses = append(filter.Statuses, workorder.Status(status))
+	}
+	for _, priority := range queryList(c, "priority") {
+		filter.Priorities = append(filter.Priorities, workorder.Priority(priority))
+	}
+	for _, state := range queryList(c, "slaState") {
+		filter.SLAStates = append(filter.SLAStates, workorder.SLAState(state))
+	}
+	for _, status := range queryList(c, "startStatus") {
+		filter.StartStatuses = append(filter.StartStatuses, workorder.StartStatus(status))
+	}
+
+	if raw := c.Query("flowVersion"); raw != "" {
+		version, err := strconv.Atoi(raw)
+		if err != nil || version < 1 {
+			return workorder.ListFilter{}, fmt.Errorf("flowVersion must be a positive integer")
+		}
+		filter.FlowVersion = version
+	}
+
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "asc":
+		filter.Ascending = true
+	case "desc":
+	default:
+		return workorder.ListFilter{}, fmt.Errorf("order must be asc or desc, got %q", order)
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return workorder.ListFilter{}, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+
+	times := []struct {
+		param  string
+		target **time.Time
+	}{
+		{"dueAfter", &filter.DueAfter},
+		{"dueBefore", &filter.DueBefore},
+		{"createdAfter", &filter.CreatedAfter},
+		{"createdBefore", &filter.CreatedBefore},
+		{"updatedAfter", &filter.UpdatedAfter},
+		{"updatedBefore", &filter.UpdatedBefore},
+	}
+	for _, t := range times {
+		raw := c.Query(t.param)
+		if raw == "" {
+			continue
+		}
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return workorder.ListFilter{}, fmt.Errorf("%s must be an RFC 3339 timestamp", t.param)
+		}
+		*t.target = &value
+	}
+
+	return filter, nil
+}
+
+func queryList(c *gin.Context, param string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(param) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func writeError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case workorder.IsNotFound(err):
+		status = http.StatusNotFound
+	case workorder.IsInvalidQuery(err), workorder.IsInvalidInput(err), workorder.IsInvalidComment(err):
+		status = http.StatusBadRequest
+	case workorder.IsInvalidTransition(err), workorder.IsTerminalState(err), workorder.IsAssignmentConflict(err), workorder.IsFlowUnavailable(err), workorder.IsNoProcess(err), workorder.IsVersionNotDeployed(err):
+		status = http.StatusConflict
+	case workorder.IsNotCandidate(err), workorder.IsNotCommentAuthor(err):
+		status = http.StatusForbidden
+	case workorder.IsIdempotencyMismatch(err):
+		status = http.StatusUnprocessableEntity
+	case workorder.IsStartFailed(err):
+		status = http.StatusBadGateway
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,119 @@
+package migration
+
+import (
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Status string
+
+const (
+	StatusQueued          Status = "queued"
+	StatusRunning         Status = "running"
+	StatusCompleted       Status = "completed"
+	StatusPartiallyFailed Status = "partially_failed"
+	StatusFailed          Status = "failed"
+)
+
+type ItemStatus string
+
+const (
+	ItemPending  ItemStatus = "pending"
+	ItemMigrated ItemStatus = "migrated"
+	ItemFailed   ItemStatus = "failed"
+	ItemSkipped  ItemStatus = "skipped"
+)
+
+func (s ItemStatus) Valid() bool {
+	switch s {
+	case ItemPending, ItemMigrated, ItemFailed, ItemSkipped:
+		return true
+	}
+	return false
+}
+
+type Instruction struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Auto   bool   `json:"auto"`
+}
+
+type PlanInput struct {
+	FlowID        string
+	SourceVersion int
+	TargetVersion int
+	Overrides     map[string]string
+	WorkOrderIDs  []string
+}
+
+type Plan struct {
+	FlowID             string        `json:"flowId"`
+	SourceVersion      int           `json:"sourceVersion"`
+	TargetVersion      int           `json:"targetVersion"`
+	SourceDefinitionID string        `json:"sourceDefinitionId"`
+	TargetDefinitionID string        `json:"targetDefinitionId"`
+	Instructions       []Instruction `json:"instructions"`
+	Unmapped           []string      `json:"unmapped"`
+	Problems           []string      `json:"problems"`
+}
+
+type Candidate struct {
+	WorkOrderID       string           `json:"workOrderId"`
+	Title             string           `json:"title"`
+	Status            workorder.Status `json:"status"`
+	CurrentStep       string           `json:"currentStep"`
+	ProcessInstanceID string           `json:"processInstanceId"`
+	Migratable        bool             `json:"migratable"`
+	Reason            string           `json:"reason,omitempty"`
+}
+
+type Preview struct {
+	Plan       Plan        `json:"plan"`
+	Total      int         `json:"total"`
+	Migratable int         `json:"migratable"`
+	WorkOrders []Candidate `json:"workOrders"`
+}
+
+type Migration struct {
+	ID                 string        `json:"id" db:"id"`
+	TenantID           string        `json:"tenantId" db:"tenant_id"`
+	FlowID             string        `json:"flowId" db:"flow_id"`
+	SourceVersion      int           `json:"sourceVersion" db:"source_version"`
+	TargetVersion      int           `json:"targetVersion" db:"target_version"`
+	SourceDefinitionID string        `json:"sourceDefinitionId" db:"source_definition_id"`
+	TargetDefinitionID string        `json:"targetDefinitionId" db:"target_definition_id"`
+	Instructions       []Instruction `json:"instructions" db:"instructions"`
+	Status             Status        `json:"status" db:"status"`
+	Total              int           `json:"total" db:"total"`
+	Migrated           int           `json:"migrated" db:"migrated"`
+	Failed             int           `json:"failed" db:"failed"`
+	Skipped            int           `json:"skipped" db:"skipped"`
+	CreatedBy          string        `json:"createdBy" db:"created_by"`
+	CreatedAt          time.Time     `json:"createdAt" db:"created_at"`
+	UpdatedAt          time.Time     `json:"updatedAt" db:"updated_at"`
+	FinishedAt         *time.Time    `json:"finishedAt,omitempty" db:"finished_at"`
+	Items              []Item        `json:"items,omitempty" db:"-"`
+}
+
+type Item struct {
+	Index             int        `json:"index" db:"idx"`
+	WorkOrderID       string     `json:"workOrderId" db:"workorder_id"`
+	ProcessInstanceID string     `json:"processInstanceId" db:"process_instance_id"`
+	CurrentStep       string     `json:"currentStep" db:"current_step"`
+	Batch             int        `json:"batch" db:"batch"`
+	Status            ItemStatus `json:"status" db:"status"`
+	Error             string     `json:"error,omitempty" db:"error"`
+	UpdatedAt         time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+func (m Migration) plan() Plan {
+	return Plan{
+		FlowID:             m.FlowID,
+		SourceVersion:      m.SourceVersion,
+		TargetVersion:      m.TargetVersion,
+		SourceDefinitionID: m.SourceDefinitionID,
+		TargetDefinitionID: m.TargetDefinitionID,
+		Instructions:       m.Instructions,
+	}
+}
@@ -0,0 +1,109 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
+)
+
+var waitStates = map[bpmn.NodeKind]bool{
+	bpmn.KindUserTask:        true,
+	bpmn.KindServiceTask:     true,
+	bpmn.KindParallelGateway: true,
+}
+
+func buildPlan(source, target flow.Snapshot, overrides map[string]string) (Plan, error) {
+	sourceGraph, err := parseVersion(source)
+	if err != nil {
+		return Plan{}, err
+	}
+	targetGraph, err := parseVersion(target)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	targetNodes := make(map[string]bpmn.Node, len(targetGraph.Nodes))
+	for _, n := range targetGraph.Nodes {
+		targetNodes[n.ID] = n
+	}
+
+	plan := Plan{
+		FlowID:             source.FlowID,
+		SourceVersion:      source.Version,
+		TargetVersion:      target.Version,
+		SourceDefinitionID: source.ProcessDefinitionID,
+		TargetDefinitionID: target.ProcessDefinitionID,
+		Instructions:       []Instruction{},
+		Unmapped:           []string{},
+		Problems:           []string{},
+	}
+
+	var problems []string
+	sourceIDs := make(map[string]bool)
+	for _, n := range sourceGraph.Nodes {
+		if !waitStates[n.Kind] {
+			continue
+		}
+		sourceIDs[n.ID] = true
+
+		targetID, overridden := overrides[n.ID]
+		if !overridden {
+			targetID = n.ID
+		}
+		if targetID == "" {
+			plan.Unmapped = append(plan.Unmapped, n.ID)
+			continue
+		}
+
+		t, ok := targetNodes[targetID]
+		switch {
+		case !ok && overridden:
+			problems = append(problems, fmt.Sprintf("override %s: activity %s does not exist in version %d", n.ID, targetID, target.Version))
+		case ok && t.Kind != n.Kind && overridden:
+			problems = append(problems, fmt.Sprintf("override %s: cannot map a %s to the %s %s", n.ID, n.Kind, t.Kind, targetID))
+		case !ok, t.Kind != n.Kind:
+			plan.Unmapped = append(plan.Unmapped, n.ID)
+		default:
+			plan.Instructions = append(plan.Instructions, Instruction{Source: n.ID, Target: targetID, Auto: !overridden})
+		}
+	}
+
+	unknown := make([]string, 0)
+	for id := range overrides {
+		if !sourceIDs[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	sort.Strings(unknown)
+	for _, id := range unknown {
+		problems = append(problems, fmt.Sprintf("override %s: no such activity in version %d", id, source.Version))
+	}
+
+	if len(problems) > 0 {
+		return Plan{}, &PlanError{Problems: problems}
+	}
+	return plan, nil
+}
+
+func parseVersion(snapshot flow.Snapshot) (bpmn.Graph, error) {
+	graph, problems := bpmn.Parse(snapshot.Definition)
+	if len(problems) == 0 {
+		return graph, nil
+	}
+
+	messages := make([]string, len(problems))
+	for i, p := range problems {
+		messages[i] = fmt.Sprintf("version %d: %s", snapshot.Version, p.Message)
+	}
+	return bpmn.Graph{}, &PlanError{Problems: messages}
+}
+
+func (p Plan) mappedSteps() map[string]bool {
+	steps := make(map[string]bool, len(p.Instructions))
+	for _, instruction := range p.Instructions {
+		steps[bpmn.ElementID(instruction.Source)] = true
+	}
+	return steps
+}
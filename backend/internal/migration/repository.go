@@ -0,0 +1,226 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+const migrationColumns = `id, tenant_id, flow_id, source_version, target_version, source_definition_id, target_definition_id, instructions, status, total, migrated, failed, skipped, created_by, created_at, updated_at, finished_at`
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, m Migration, items []Item) (Migration, error) {
+	const insertMigration = `INSERT INTO flow_migrations (id, tenant_id, flow_id, source_version, target_version, source_definition_id, target_definition_id, instructions, status, total, skipped, created_by, created_at, updated_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$13)`
+	const insertItems = `INSERT INTO flow_migration_items (migration_id, idx, workorder_id, process_instance_id, current_step, batch, status, error, updated_at)
+SELECT $1, item.idx - 1, item.workorder_id, item.process_instance_id, item.current_step, item.batch, item.status, NULLIF(item.error, ''), $8
+FROM unnest($2::text[], $3::text[], $4::text[], $5::int[], $6::text[], $7::text[]) WITH ORDINALITY AS item(workorder_id, process_instance_id, current_step, batch, status, error, idx)`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	instructions, err := json.Marshal(m.Instructions)
+	if err != nil {
+		return Migration{}, fmt.Errorf("marshal instructions: %w", err)
+	}
+
+	now := time.Now().UTC()
+	m.TenantID = tenantID
+	m.CreatedAt = now
+	m.UpdatedAt = now
+
+	var (
+		workOrderIDs = make([]string, len(items))
+		instanceIDs  = make([]string, len(items))
+		steps        = make([]string, len(items))
+		batches      = make([]int64, len(items))
+		statuses     = make([]string, len(items))
+		errs         = make([]string, len(items))
+	)
+	for i, item := range items {
+		workOrderIDs[i] = item.WorkOrderID
+		instanceIDs[i] = item.ProcessInstanceID
+		steps[i] = item.CurrentStep
+		batches[i] = int64(item.Batch)
+		statuses[i] = string(item.Status)
+		errs[i] = item.Error
+	}
+
+	exec := persistence.ExecutorFromContext(ctx, r.db)
+	if _, err := exec.ExecContext(ctx, insertMigration, m.ID, m.TenantID, m.FlowID, m.SourceVersion, m.TargetVersion, m.SourceDefinitionID, m.TargetDefinitionID, instructions, m.Status, m.Total, m.Skipped, m.CreatedBy, now); err != nil {
+		return Migration{}, fmt.Errorf("insert migration: %w", err)
+	}
+	if _, err := exec.ExecContext(ctx, insertItems, m.ID, workOrderIDs, instanceIDs, steps, batches, statuses, errs, now); err != nil {
+		return Migration{}, fmt.Errorf("insert migration items: %w", err)
+	}
+
+	return m, nil
+}
+
+func (r *repository) Get(ctx context.Context, flowID, id string) (Migration, error) {
+	const query = `SELECT ` + migrationColumns + ` FROM flow_migrations WHERE id = $1 AND flow_id = $2 AND tenant_id = $3`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	m, err := scanMigration(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, flowID, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Migration{}, sqlErrNotFound
+		}
+		return Migration{}, fmt.Errorf("get migration: %w", err)
+	}
+	return m, nil
+}
+
+func (r *repository) List(ctx context.Context, flowID string) ([]Migration, error) {
+	const query = `SELECT ` + migrationColumns + ` FROM flow_migrations WHERE flow_id = $1 AND tenant_id = $2 ORDER BY created_at DESC, id DESC`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, flowID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+	defer rows.Close()
+
+	migrations := []Migration{}
+	for rows.Next() {
+		m, err := scanMigration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan migration: %w", err)
+		}
+		migrations = append(migrations, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+	return migrations, nil
+}
+
+func (r *repository) ListItems(ctx context.Context, id string, status ItemStatus) ([]Item, error) {
+	const query = `SELECT i.idx, i.workorder_id, i.process_instance_id, i.current_step, i.batch, i.status, COALESCE(i.error, '') AS error, i.updated_at
+FROM flow_migration_items i JOIN flow_migrations m ON m.id = i.migration_id
+WHERE i.migration_id = $1 AND m.tenant_id = $2 AND ($3 = '' OR i.status = $3)
+ORDER BY i.idx`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &items, query, id, tenantID, string(status)); err != nil {
+		return nil, fmt.Errorf("list migration items: %w", err)
+	}
+	return items, nil
+}
+
+func (r *repository) ClaimNext(ctx context.Context, staleBefore time.Time) (Migration, error) {
+	const query = `UPDATE flow_migrations SET status = 'running', claimed_at = $1, updated_at = $1
+WHERE id = (
+    SELECT id FROM flow_migrations
+    WHERE status IN ('queued', 'running') AND (claimed_at IS NULL OR claimed_at <= $2)
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + migrationColumns
+
+	m, err := scanMigration(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, time.Now().UTC(), staleBefore))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Migration{}, sqlErrNotFound
+		}
+		return Migration{}, fmt.Errorf("claim migration: %w", err)
+	}
+	return m, nil
+}
+
+func (r *repository) Finish(ctx context.Context, id string, at time.Time) error {
+	const query = `UPDATE flow_migrations SET
+    status = CASE WHEN failed = 0 THEN 'completed' WHEN migrated = 0 THEN 'failed' ELSE 'partially_failed' END,
+    claimed_at = NULL, finished_at = $2, updated_at = $2
+WHERE id = $1 AND tenant_id = $3 AND status = 'running'
+    AND NOT EXISTS (SELECT 1 FROM flow_migration_items WHERE migration_id = $1 AND status = 'pending')`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, id, at, tenantID)
+	if err != nil {
+		return fmt.Errorf("finish migration: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sqlErrItemsPending
+	}
+	return nil
+}
+
+func (r *repository) RecordItem(ctx context.Context, id string, item Item) error {
+	const query = `WITH item AS (
+    UPDATE flow_migration_items SET status = $3, error = NULLIF($4, ''), updated_at = $5
+    WHERE migration_id = $1 AND idx = $2 AND status = 'pending'
+    RETURNING status
+)
+UPDATE flow_migrations SET
+    migrated = migrated + (SELECT count(*) FROM item WHERE status = 'migrated'),
+    failed = failed + (SELECT count(*) FROM item WHERE status = 'failed'),
+    claimed_at = $5,
+    updated_at = $5
+WHERE id = $1 AND tenant_id = $6`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, id, item.Index, item.Status, item.Error, item.UpdatedAt, tenantID); err != nil {
+		return fmt.Errorf("record migration item: %w", err)
+	}
+	return nil
+}
+
+func scanMigration(scanner interface {
+	Scan(dest ...any) error
+}) (Migration, error) {
+	var (
+		m            Migration
+		instructions []byte
+	)
+
+	if err := scanner.Scan(&m.ID, &m.TenantID, &m.FlowID, &m.SourceVersion, &m.TargetVersion, &m.SourceDefinitionID, &m.TargetDefinitionID, &instructions, &m.Status, &m.Total, &m.Migrated, &m.Failed, &m.Skipped, &m.CreatedBy, &m.CreatedAt, &m.UpdatedAt, &m.FinishedAt); err != nil {
+		return Migration{}, err
+	}
+
+	if len(instructions) > 0 {
+		if err := json.Unmarshal(instructions, &m.Instructions); err != nil {
+			return Migration{}, fmt.Errorf("unmarshal instructions: %w", err)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,441 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Service interface {
+	Preview(ctx context.Context, input PlanInput) (Preview, error)
+	Start(ctx context.Context, input PlanInput) (Migration, error)
+	List(ctx context.Context, flowID string) ([]Migration, error)
+	Get(ctx context.Context, flowID, id string, itemStatus ItemStatus) (Migration, error)
+}
+
+type Repository interface {
+	Create(ctx context.Context, m Migration, items []Item) (Migration, error)
+	Get(ctx context.Context, flowID, id string) (Migration, error)
+	List(ctx context.Context, flowID string) ([]Migration, error)
+	ListItems(ctx context.Context, id string, status ItemStatus) ([]Item, error)
+	ClaimNext(ctx context.Context, staleBefore time.Time) (Migration, error)
+	RecordItem(ctx context.Context, id string, item Item) error
+	Finish(ctx context.Context, id string, at time.Time) error
+}
+
+type FlowVersions interface {
+	GetVersion(ctx context.Context, id string, version int) (flow.Snapshot, error)
+}
+
+type Engine interface {
+	ValidateMigration(ctx context.Context, plan Plan) ([]string, error)
+	ExecuteMigration(ctx context.Context, plan Plan, processInstanceIDs []string) error
+}
+
+type InputError struct {
+	Message string
+}
+
+func (e *InputError) Error() string { return e.Message }
+
+func IsInvalidInput(err error) bool {
+	var target *InputError
+	return errors.As(err, &target)
+}
+
+type PlanError struct {
+	Problems []string
+}
+
+func (e *PlanError) Error() string {
+	return "invalid migration plan: " + strings.Join(e.Problems, "; ")
+}
+
+func AsPlanError(err error) (*PlanError, bool) {
+	var target *PlanError
+	ok := errors.As(err, &target)
+	return target, ok
+}
+
+type VersionError struct {
+	FlowID  string
+	Version int
+	Reason  string
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("flow %s version %d %s", e.FlowID, e.Version, e.Reason)
+}
+
+func IsVersionUnavailable(err error) bool {
+	var target *VersionError
+	return errors.As(err, &target)
+}
+
+type notFoundError struct{ id string }
+
+func (e notFoundError) Error() string { return fmt.Sprintf("migration %s not found", e.id) }
+
+func (notFoundError) NotFound() {}
+
+func IsNotFound(err error) bool {
+	var target interface{ NotFound() }
+	return errors.As(err, &target)
+}
+
+var (
+	sqlErrNotFound     = errors.New("migration not found")
+	sqlErrItemsPending = errors.New("migration has pending items")
+)
+
+var activeStatuses = []workorder.Status{
+	workorder.StatusPending,
+	workorder.StatusRunning,
+	workorder.StatusFailed,
+	workorder.StatusSuspended,
+}
+
+type Migrator struct {
+	repo       Repository
+	tx         persistence.Transactor
+	flows      FlowVersions
+	workorders workorder.Service
+	engine     Engine
+	cfg        config.MigrationConfig
+	wake       chan struct{}
+}
+
+func NewMigrator(repo Repository, tx persistence.Transactor, flows FlowVersions, workorders workorder.Service, engine Engine, cfg config.MigrationConfig) *Migrator {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 1
+	}
+	return &Migrator{
+		repo:       repo,
+		tx:         tx,
+		flows:      flows,
+		workorders: workorders,
+		engine:     engine,
+		cfg:        cfg,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func (m *Migrator) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := m.repo.ClaimNext(ctx, time.Now().UTC().Add(-m.cfg.ClaimTimeout))
+		if err == nil {
+			m.execute(ctx, job)
+			continue
+		}
+		if !errors.Is(err, sqlErrNotFound) && ctx.Err() == nil {
+			log.Printf("migrator: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.wake:
+		}
+	}
+}
+
+func (m *Migrator) Preview(ctx context.Context, input PlanInput) (Preview, error) {
+	plan, err := m.plan(ctx, input)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	candidates, err := m.candidates(ctx, plan, input.WorkOrderIDs)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	preview := Preview{Plan: plan, Total: len(candidates), WorkOrders: candidates}
+	for _, c := range candidates {
+		if c.Migratable {
+			preview.Migratable++
+		}
+	}
+	return preview, nil
+}
+
+func (m *Migrator) Start(ctx context.Context, input PlanInput) (Migration, error) {
+	if m.engine == nil {
+		return Migration{}, &InputError{Message: "process migration requires a camunda engine"}
+	}
+
+	preview, err := m.Preview(ctx, input)
+	if err != nil {
+		return Migration{}, err
+	}
+	if len(preview.Plan.Problems) > 0 {
+		return Migration{}, &PlanError{Problems: preview.Plan.Problems}
+	}
+	if preview.Migratable == 0 {
+		return Migration{}, &InputError{Message: "no workorders can be migrated with this plan"}
+	}
+
+	items := make([]Item, len(preview.WorkOrders))
+	pending := 0
+	for i, c := range preview.WorkOrders {
+		items[i] = Item{
+			Index:             i,
+			WorkOrderID:       c.WorkOrderID,
+			ProcessInstanceID: c.ProcessInstanceID,
+			CurrentStep:       c.CurrentStep,
+			Status:            ItemSkipped,
+			Error:             c.Reason,
+		}
+		if c.Migratable {
+			items[i].Status = ItemPending
+			items[i].Batch = pending/m.cfg.BatchSize + 1
+			pending++
+		}
+	}
+
+	plan := preview.Plan
+	var created Migration
+	err = m.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = m.repo.Create(ctx, Migration{
+			ID:                 uuid.NewString(),
+			FlowID:             plan.FlowID,
+			SourceVersion:      plan.SourceVersion,
+			TargetVersion:      plan.TargetVersion,
+			SourceDefinitionID: plan.SourceDefinitionID,
+			TargetDefinitionID: plan.TargetDefinitionID,
+			Instructions:       plan.Instructions,
+			Status:             StatusQueued,
+			Total:              len(items),
+			Skipped:            len(items) - pending,
+			CreatedBy:          workorder.ActorFromContext(ctx),
+		}, items)
+		return err
+	})
+	if err != nil {
+		return Migration{}, err
+	}
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return created, nil
+}
+
+func (m *Migrator) List(ctx context.Context, flowID string) ([]Migration, error) {
+	return m.repo.List(ctx, flowID)
+}
+
+func (m *Migrator) Get(ctx context.Context, flowID, id string, itemStatus ItemStatus) (Migration, error) {
+	if itemStatus != "" && !itemStatus.Valid() {
+		return Migration{}, &InputError{Message: fmt.Sprintf("unknown item status %q", itemStatus)}
+	}
+
+	found, err := m.repo.Get(ctx, flowID, id)
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Migration{}, notFoundError{id: id}
+		}
+		return Migration{}, err
+	}
+
+	if found.Items, err = m.repo.ListItems(ctx, id, itemStatus); err != nil {
+		return Migration{}, err
+	}
+	return found, nil
+}
+
+func (m *Migrator) plan(ctx context.Context, input PlanInput) (Plan, error) {
+	switch {
+	case input.SourceVersion < 1 || input.TargetVersion < 1:
+		return Plan{}, &InputError{Message: "sourceVersion and targetVersion must be positive integers"}
+	case input.SourceVersion == input.TargetVersion:
+		return Plan{}, &InputError{Message: "sourceVersion and targetVersion must differ"}
+	}
+
+	source, err := m.deployedVersion(ctx, input.FlowID, input.SourceVersion)
+	if err != nil {
+		return Plan{}, err
+	}
+	target, err := m.deployedVersion(ctx, input.FlowID, input.TargetVersion)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	plan, err := buildPlan(source, target, input.Overrides)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	if m.engine != nil && len(plan.Instructions) > 0 {
+		problems, err := m.engine.ValidateMigration(ctx, plan)
+		if err != nil {
+			return Plan{}, fmt.Errorf("validate migration plan: %w", err)
+		}
+		plan.Problems = append(plan.Problems, problems...)
+	}
+	return plan, nil
+}
+
+func (m *Migrator) deployedVersion(ctx context.Context, flowID string, version int) (flow.Snapshot, error) {
+	snapshot, err := m.flows.GetVersion(ctx, flowID, version)
+	if err != nil {
+		return flow.Snapshot{}, err
+	}
+	switch {
+	case snapshot.PublishedAt == nil:
+		return flow.Snapshot{}, &VersionError{FlowID: flowID, Version: version, Reason: "has never been published"}
+	case snapshot.ProcessDefinitionID == "":
+		return flow.Snapshot{}, &VersionError{FlowID: flowID, Version: version, Reason: "has no deployed process definition"}
+	}
+	return snapshot, nil
+}
+
+func (m *Migrator) candidates(ctx context.Context, plan Plan, ids []string) ([]Candidate, error) {
+	query := workorder.ListFilter{
+		Statuses:    activeStatuses,
+		FlowID:      plan.FlowID,
+		FlowVersion: plan.SourceVersion,
+		Ascending:   true,
+		Limit:       workorder.MaxPageSize,
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	mapped := plan.mappedSteps()
+	candidates := []Candidate{}
+	for {
+		page, err := m.workorders.List(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, wo := range page.Items {
+			if wo.ProcessInstanceID == "" || (len(wanted) > 0 && !wanted[wo.ID]) {
+				continue
+			}
+			delete(wanted, wo.ID)
+			candidates = append(candidates, candidate(plan, mapped, wo))
+		}
+		if len(candidates) > m.cfg.MaxItems {
+			return nil, &InputError{Message: fmt.Sprintf("more than %d workorders run on version %d; select workOrderIds to migrate in parts", m.cfg.MaxItems, plan.SourceVersion)}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	for _, id := range ids {
+		if wanted[id] {
+			return nil, &InputError{Message: fmt.Sprintf("workorder %s is not an active workorder on flow %s version %d", id, plan.FlowID, plan.SourceVersion)}
+		}
+	}
+	return candidates, nil
+}
+
+func candidate(plan Plan, mapped map[string]bool, wo workorder.WorkOrder) Candidate {
+	c := Candidate{
+		WorkOrderID:       wo.ID,
+		Title:             wo.Title,
+		Status:            wo.Status,
+		CurrentStep:       wo.CurrentStep,
+		ProcessInstanceID: wo.ProcessInstanceID,
+		Migratable:        true,
+	}
+	switch {
+	case wo.ProcessDefinitionID != "" && wo.ProcessDefinitionID != plan.SourceDefinitionID:
+		c.Migratable, c.Reason = false, fmt.Sprintf("process instance runs on definition %s, not %s", wo.ProcessDefinitionID, plan.SourceDefinitionID)
+	case wo.CurrentStep != "" && !mapped[wo.CurrentStep]:
+		c.Migratable, c.Reason = false, fmt.Sprintf("current step %s has no mapping to version %d", wo.CurrentStep, plan.TargetVersion)
+	}
+	return c
+}
+
+func (m *Migrator) execute(stop context.Context, job Migration) {
+	ctx := workorder.WithActor(tenant.WithTenant(context.WithoutCancel(stop), job.TenantID), job.CreatedBy)
+
+	items, err := m.repo.ListItems(ctx, job.ID, ItemPending)
+	if err != nil {
+		log.Printf("migration %s: %v", job.ID, err)
+		return
+	}
+
+	for start := 0; start < len(items); {
+		if stop.Err() != nil {
+			log.Printf("migration %s: migrator stopped with %d items pending", job.ID, len(items)-start)
+			return
+		}
+
+		end := start + 1
+		for end < len(items) && items[end].Batch == items[start].Batch {
+			end++
+		}
+		m.migrateBatch(ctx, job, items[start:end])
+		start = end
+	}
+
+	if err := m.repo.Finish(ctx, job.ID, time.Now().UTC()); err != nil {
+		log.Printf("migration %s: %v", job.ID, err)
+	}
+}
+
+func (m *Migrator) migrateBatch(ctx context.Context, job Migration, batch []Item) {
+	instanceIDs := make([]string, len(batch))
+	for i, item := range batch {
+		instanceIDs[i] = item.ProcessInstanceID
+	}
+
+	if err := m.engine.ExecuteMigration(ctx, job.plan(), instanceIDs); err != nil {
+		if len(batch) == 1 {
+			m.record(ctx, job.ID, batch[0], ItemFailed, err.Error())
+			return
+		}
+		for _, item := range batch {
+			m.migrateBatch(ctx, job, []Item{item})
+		}
+		return
+	}
+
+	for _, item := range batch {
+		if _, err := m.workorders.RecordMigration(ctx, item.WorkOrderID, job.SourceVersion, job.TargetVersion, job.TargetDefinitionID); err != nil {
+			m.record(ctx, job.ID, item, ItemFailed, fmt.Sprintf("process instance migrated but workorder not updated: %v", err))
+			continue
+		}
+		m.record(ctx, job.ID, item, ItemMigrated, "")
+	}
+}
+
+func (m *Migrator) record(ctx context.Context, id string, item Item, status ItemStatus, message string) {
+	item.Status = status
+	item.Error = message
+	item.UpdatedAt = time.Now().UTC()
+	if err := m.repo.RecordItem(ctx, id, item); err != nil {
+		log.Printf("migration %s item %d: %v", id, item.Index, err)
+	}
+}
+
+func (m *Migrator) withinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.tx == nil {
+		return fn(ctx)
+	}
+	return m.tx.WithinTransaction(ctx, fn)
+}
@@ -3,19 +3,29 @@ package mq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 
 	"github.com/streadway/amqp"
 
 	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
-	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
-	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
 )
 
+type Envelope struct {
+	ID    int64
+	Event string
+	Data  json.RawMessage
+}
+
 type Publisher struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	cfg     config.QueueConfig
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	cfg      config.QueueConfig
+	mu       sync.Mutex
+	confirms chan amqp.Confirmation
+	sent     uint64
 }
 
 func NewPublisher(cfg config.QueueConfig) (*Publisher, error) {
@@ -33,34 +43,27 @@ func NewPublisher(cfg config.QueueConfig) (*Publisher, error) {
 		return nil, fmt.Errorf("declare exchange: %w", err)
 	}
 
-	return &Publisher{conn: conn, channel: ch, cfg: cfg}, nil
-}
-
-func (p *Publisher) PublishFlowCreated(ctx context.Context, flow flow.Flow) error {
-	return p.publish(ctx, "flow.created", flow)
-}
-
-func (p *Publisher) PublishFlowUpdated(ctx context.Context, flow flow.Flow) error {
-	return p.publish(ctx, "flow.updated", flow)
-}
-
-func (p *Publisher) PublishWorkOrderCreated(ctx context.Context, wo workorder.WorkOrder) error {
-	return p.publish(ctx, "workorder.created", wo)
-}
+	if err := ch.Confirm(false); err != nil {
+		return nil, fmt.Errorf("enable publisher confirms: %w", err)
+	}
 
-func (p *Publisher) PublishWorkOrderCompleted(ctx context.Context, wo workorder.WorkOrder) error {
-	return p.publish(ctx, "workorder.completed", wo)
+	return &Publisher{
+		conn:     conn,
+		channel:  ch,
+		cfg:      cfg,
+		confirms: ch.NotifyPublish(make(chan amqp.Confirmation, 1)),
+	}, nil
 }
 
-func (p *Publisher) publish(ctx context.Context, event string, payload any) error {
+func (p *Publisher) Publish(ctx context.Context, env Envelope) error {
 	if p == nil || p.channel == nil {
-		return nil
+		return errors.New("publisher is not connected")
 	}
 
 	body, err := json.Marshal(struct {
-		Event string `json:"event"`
-		Data  any    `json:"data"`
-	}{Event: event, Data: payload})
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}{Event: env.Event, Data: env.Data})
 	if err != nil {
 		return fmt.Errorf("marshal message: %w", err)
 	}
@@ -69,10 +72,38 @@ func (p *Publisher) publish(ctx context.Context, event string, payload any) erro
 		return err
 	}
 
-	return p.channel.Publish(p.cfg.Exchange, p.cfg.RoutingKey, false, false, amqp.Publishing{
-		ContentType: p.cfg.ContentType,
-		Body:        body,
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err = p.channel.Publish(p.cfg.Exchange, p.cfg.RoutingKey, false, false, amqp.Publishing{
+		ContentType:  p.cfg.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    strconv.FormatInt(env.ID, 10),
+		Type:         env.Event,
+		Body:         body,
 	})
+	if err != nil {
+		return fmt.Errorf("publish %s: %w", env.Event, err)
+	}
+	p.sent++
+
+	for {
+		select {
+		case confirm, ok := <-p.confirms:
+			if !ok {
+				return errors.New("channel closed before confirm")
+			}
+			if confirm.DeliveryTag < p.sent {
+				continue
+			}
+			if !confirm.Ack {
+				return fmt.Errorf("broker rejected %s", env.Event)
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 }
 
 func (p *Publisher) Close() error {
@@ -0,0 +1,98 @@
+package mq
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/outbox"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+)
+
+type OutboxStore interface {
+	LockPending(ctx context.Context, limit, maxAttempts int) ([]outbox.Message, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, cause error, nextAttemptAt time.Time) error
+	Stats(ctx context.Context, maxAttempts int) (outbox.Stats, error)
+}
+
+type Relay struct {
+	store     OutboxStore
+	tx        persistence.Transactor
+	publisher *Publisher
+	cfg       config.OutboxConfig
+}
+
+func NewRelay(store OutboxStore, tx persistence.Transactor, publisher *Publisher, cfg config.OutboxConfig) *Relay {
+	return &Relay{store: store, tx: tx, publisher: publisher, cfg: cfg}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	if r.publisher == nil {
+		log.Printf("outbox relay disabled: no queue publisher")
+		return
+	}
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.drain(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("outbox relay: %v", err)
+		}
+		if n == r.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) Stats(ctx context.Context) (outbox.Stats, error) {
+	return r.store.Stats(ctx, r.cfg.MaxAttempts)
+}
+
+func (r *Relay) drain(ctx context.Context) (int, error) {
+	var processed int
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		messages, err := r.store.LockPending(ctx, r.cfg.BatchSize, r.cfg.MaxAttempts)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			pubErr := r.publisher.Publish(ctx, Envelope{ID: msg.ID, Event: msg.Event, Data: msg.Payload})
+			if pubErr != nil {
+				log.Printf("outbox relay: publish %s #%d: %v", msg.Event, msg.ID, pubErr)
+				if err := r.store.MarkFailed(ctx, msg.ID, pubErr, time.Now().Add(r.backoff(msg.Attempts))); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := r.store.MarkPublished(ctx, msg.ID); err != nil {
+				return err
+			}
+		}
+
+		processed = len(messages)
+		return nil
+	})
+	return processed, err
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.cfg.RetryBackoff
+	for i := 0; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+	return delay
+}
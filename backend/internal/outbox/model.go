@@ -0,0 +1,25 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Message struct {
+	ID            int64           `json:"id" db:"id"`
+	Event         string          `json:"event" db:"event"`
+	AggregateType string          `json:"aggregateType" db:"aggregate_type"`
+	AggregateID   string          `json:"aggregateId" db:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	LastError     string          `json:"lastError,omitempty" db:"last_error"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt" db:"next_attempt_at"`
+	PublishedAt   *time.Time      `json:"publishedAt,omitempty" db:"published_at"`
+}
+
+type Stats struct {
+	Pending         int        `json:"pending"`
+	Dead            int        `json:"dead"`
+	OldestPendingAt *time.Time `json:"oldestPendingAt,omitempty"`
+}
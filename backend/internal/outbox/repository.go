@@ -0,0 +1,100 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Enqueue(ctx context.Context, msg Message) error {
+	const query = `INSERT INTO outbox (event, aggregate_type, aggregate_id, payload, created_at, next_attempt_at) VALUES ($1,$2,$3,$4,$5,$5)`
+
+	now := time.Now().UTC()
+	_, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, msg.Event, msg.AggregateType, msg.AggregateID, []byte(msg.Payload), now)
+	if err != nil {
+		return fmt.Errorf("insert outbox message: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) LockPending(ctx context.Context, limit, maxAttempts int) ([]Message, error) {
+	const query = `SELECT id, event, aggregate_type, aggregate_id, payload, attempts, COALESCE(last_error, ''), created_at, next_attempt_at
+FROM outbox
+WHERE published_at IS NULL AND attempts < $1 AND next_attempt_at <= $2
+ORDER BY id
+LIMIT $3
+FOR UPDATE SKIP LOCKED`
+
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, maxAttempts, time.Now().UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("lock outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var (
+			msg     Message
+			payload []byte
+		)
+		if err := rows.Scan(&msg.ID, &msg.Event, &msg.AggregateType, &msg.AggregateID, &payload, &msg.Attempts, &msg.LastError, &msg.CreatedAt, &msg.NextAttemptAt); err != nil {
+			return nil, fmt.Errorf("scan outbox message: %w", err)
+		}
+		msg.Payload = payload
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+func (r *Repository) MarkPublished(ctx context.Context, id int64) error {
+	const query = `UPDATE outbox SET published_at = $2, attempts = attempts + 1, last_error = NULL WHERE id = $1`
+
+	if _, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, id, time.Now().UTC()); err != nil {
+		return fmt.Errorf("mark outbox message published: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) MarkFailed(ctx context.Context, id int64, cause error, nextAttemptAt time.Time) error {
+	const query = `UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`
+
+	if _, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, id, cause.Error(), nextAttemptAt.UTC()); err != nil {
+		return fmt.Errorf("mark outbox message failed: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) Stats(ctx context.Context, maxAttempts int) (Stats, error) {
+	const query = `SELECT
+	COUNT(*) FILTER (WHERE attempts < $1),
+	COUNT(*) FILTER (WHERE attempts >= $1),
+	MIN(created_at) FILTER (WHERE attempts < $1)
+FROM outbox
+WHERE published_at IS NULL`
+
+	var (
+		stats  Stats
+		oldest sql.NullTime
+	)
+	if err := persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, maxAttempts).Scan(&stats.Pending, &stats.Dead, &oldest); err != nil {
+		return Stats{}, fmt.Errorf("outbox stats: %w", err)
+	}
+	if oldest.Valid {
+		stats.OldestPendingAt = &oldest.Time
+	}
+	return stats, nil
+}
@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+const (
+	aggregateFlow      = "flow"
+	aggregateWorkOrder = "workorder"
+)
+
+type Writer struct {
+	repo *Repository
+}
+
+func NewWriter(repo *Repository) *Writer {
+	return &Writer{repo: repo}
+}
+
+func (w *Writer) PublishFlowCreated(ctx context.Context, f flow.Flow) error {
+	return w.enqueue(ctx, "flow.created", aggregateFlow, f.ID, f)
+}
+
+func (w *Writer) PublishFlowUpdated(ctx context.Context, f flow.Flow) error {
+	return w.enqueue(ctx, "flow.updated", aggregateFlow, f.ID, f)
+}
+
+func (w *Writer) PublishFlowPublished(ctx context.Context, f flow.Flow) error {
+	return w.enqueue(ctx, "flow.published", aggregateFlow, f.ID, f)
+}
+
+func (w *Writer) PublishFlowDeprecated(ctx context.Context, f flow.Flow) error {
+	return w.enqueue(ctx, "flow.deprecated", aggregateFlow, f.ID, f)
+}
+
+func (w *Writer) PublishWorkOrderCreated(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.created", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderRunning(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.running", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderFailed(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.failed", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderCompleted(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.completed", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderCancelled(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.cancelled", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderSuspended(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.suspended", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderResumed(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.resumed", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderSLAWarning(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.sla_warning", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderSLABreached(ctx context.Context, wo workorder.WorkOrder) error {
+	return w.enqueue(ctx, "workorder.sla_breached", aggregateWorkOrder, wo.ID, wo)
+}
+
+func (w *Writer) PublishWorkOrderAssigned(ctx context.Context, wo workorder.WorkOrder, previous string) error {
+	return w.enqueue(ctx, "workorder.assigned", aggregateWorkOrder, wo.ID, struct {
+		workorder.WorkOrder
+		PreviousAssignee string `json:"previousAssignee"`
+	}{wo, previous})
+}
+
+func (w *Writer) PublishWorkOrderMigrated(ctx context.Context, wo workorder.WorkOrder, fromVersion int) error {
+	return w.enqueue(ctx, "workorder.migrated", aggregateWorkOrder, wo.ID, struct {
+		workorder.WorkOrder
+		PreviousFlowVersion int `json:"previousFlowVersion"`
+	}{wo, fromVersion})
+}
+
+func (w *Writer) enqueue(ctx context.Context, event, aggregateType, aggregateID string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s payload: %w", event, err)
+	}
+
+	return w.repo.Enqueue(ctx, Message{
+		Event:         event,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       data,
+	})
+}
@@ -3,6 +3,7 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -74,3 +75,53 @@ func TxFromContext(ctx context.Context) *sqlx.Tx {
 	}
 	return nil
 }
+
+func ExecutorFromContext(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return db
+}
+
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func (d *Database) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if TxFromContext(ctx) != nil {
+		return WithSavepoint(ctx, fn)
+	}
+	return WithTransaction(ctx, d.DB, func(ctx context.Context, _ *sqlx.Tx) error {
+		return fn(ctx)
+	})
+}
+
+type savepointKey struct{}
+
+func WithSavepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx := TxFromContext(ctx)
+	if tx == nil {
+		return errors.New("savepoint requires an active transaction")
+	}
+
+	depth, _ := ctx.Value(savepointKey{}).(int)
+	depth++
+	name := fmt.Sprintf("sp_%d", depth)
+	ctx = context.WithValue(ctx, savepointKey{}, depth)
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("create savepoint %s: %w", name, err)
+	}
+
+	if err := fn(ctx); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return fmt.Errorf("rollback to savepoint %s: %v (original err: %w)", name, rbErr, err)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("release savepoint %s: %w", name, err)
+	}
+	return nil
+}
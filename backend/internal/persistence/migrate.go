@@ -0,0 +1,321 @@
+package persistence
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const migrationLockID int64 = 0x70666c6f77
+
+var migrationFile = regexp.MustCompile(`^(\d+)_([a-z0-9_]+?)(\.down)?\.sql$`)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Checksum string
+	up       string
+	down     string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+func (m Migration) Reversible() bool {
+	return m.down != ""
+}
+
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	Modified  bool       `json:"modified"`
+	Unknown   bool       `json:"unknown"`
+}
+
+type ChecksumError struct {
+	Migration string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("migration %s was modified after it was applied", e.Migration)
+}
+
+type IrreversibleError struct {
+	Migration string
+}
+
+func (e *IrreversibleError) Error() string {
+	return fmt.Sprintf("migration %s has no down script", e.Migration)
+}
+
+type appliedMigration struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+type Migrator struct {
+	db     *sqlx.DB
+	source fs.FS
+}
+
+func NewMigrator(db *sqlx.DB, source fs.FS) *Migrator {
+	return &Migrator{db: db, source: source}
+}
+
+func LoadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version", entry.Name())
+		}
+		data, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] != "" {
+			m.down = string(data)
+			continue
+		}
+		m.up = string(data)
+		sum := sha256.Sum256(data)
+		m.Checksum = hex.EncodeToString(sum[:])
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %s has a down script but no up script", m)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	migrations, err := LoadMigrations(m.source)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = m.withLock(ctx, func(conn *sqlx.Conn) error {
+		existing, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verifyChecksums(migrations, existing); err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := existing[migration.Version]; ok {
+				continue
+			}
+			if err := inTx(ctx, conn, func(tx *sqlx.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+					migration.Version, migration.Name, migration.Checksum, time.Now().UTC())
+				return err
+			}); err != nil {
+				return fmt.Errorf("apply migration %s: %w", migration, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations(m.source)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = migration
+	}
+
+	var reverted []Migration
+	err = m.withLock(ctx, func(conn *sqlx.Conn) error {
+		existing, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verifyChecksums(migrations, existing); err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(existing))
+		for version := range existing {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if steps < len(versions) {
+			versions = versions[:steps]
+		}
+
+		for _, version := range versions {
+			migration, ok := known[version]
+			if !ok {
+				return &IrreversibleError{Migration: fmt.Sprintf("%04d_%s", version, existing[version].Name)}
+			}
+			if !migration.Reversible() {
+				return &IrreversibleError{Migration: migration.String()}
+			}
+			if err := inTx(ctx, conn, func(tx *sqlx.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("revert migration %s: %w", migration, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations(m.source)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracked bool
+	if err := m.db.GetContext(ctx, &tracked, `SELECT to_regclass('schema_migrations') IS NOT NULL`); err != nil {
+		return nil, fmt.Errorf("check schema_migrations: %w", err)
+	}
+	existing := map[int64]appliedMigration{}
+	if tracked {
+		if existing, err = appliedMigrations(ctx, m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []MigrationStatus
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := existing[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Modified = row.Checksum != migration.Checksum
+			delete(existing, migration.Version)
+		}
+		result = append(result, status)
+	}
+	for _, row := range existing {
+		appliedAt := row.AppliedAt
+		result = append(result, MigrationStatus{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) (err error) {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, unlockErr := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID); unlockErr != nil && err == nil {
+			err = fmt.Errorf("release migration lock: %w", unlockErr)
+		}
+	}()
+
+	const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL
+)`
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func appliedMigrations(ctx context.Context, q sqlx.QueryerContext) (map[int64]appliedMigration, error) {
+	var rows []appliedMigration
+	if err := sqlx.SelectContext(ctx, q, &rows, `SELECT version, name, checksum, applied_at FROM schema_migrations`); err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	result := make(map[int64]appliedMigration, len(rows))
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+func verifyChecksums(migrations []Migration, applied map[int64]appliedMigration) error {
+	for _, migration := range migrations {
+		row, ok := applied[migration.Version]
+		if ok && row.Checksum != migration.Checksum {
+			return &ChecksumError{Migration: migration.String()}
+		}
+	}
+	return nil
+}
+
+func inTx(ctx context.Context, conn *sqlx.Conn, fn func(tx *sqlx.Tx) error) error {
+	tx, err := conn.BeginTxx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("rollback tx: %v (original err: %w)", rbErr, err)
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workorders;
+DROP TABLE IF EXISTS flows;
@@ -0,0 +1,3 @@
+ALTER TABLE workorders DROP COLUMN IF EXISTS flow_version;
+
+DROP TABLE IF EXISTS flow_versions;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS flow_versions (
+    flow_id TEXT NOT NULL REFERENCES flows(id),
+    version INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT DEFAULT '',
+    definition JSONB NOT NULL,
+    metadata JSONB DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (flow_id, version)
+);
+
+INSERT INTO flow_versions (flow_id, version, name, description, definition, metadata, created_at)
+SELECT id, version, name, description, definition, metadata, updated_at FROM flows
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS flow_version INTEGER NOT NULL DEFAULT 1;
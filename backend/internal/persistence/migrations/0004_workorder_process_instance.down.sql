@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_workorders_process_instance_id;
+
+ALTER TABLE workorders DROP COLUMN IF EXISTS business_key;
+ALTER TABLE workorders DROP COLUMN IF EXISTS process_definition_id;
+ALTER TABLE workorders DROP COLUMN IF EXISTS process_instance_id;
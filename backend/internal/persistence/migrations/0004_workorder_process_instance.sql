@@ -0,0 +1,5 @@
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS process_instance_id TEXT;
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS process_definition_id TEXT;
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS business_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workorders_process_instance_id ON workorders(process_instance_id) WHERE process_instance_id IS NOT NULL;
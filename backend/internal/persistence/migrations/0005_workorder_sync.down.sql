@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workorders_sync;
+
+ALTER TABLE workorders DROP COLUMN IF EXISTS synced_at;
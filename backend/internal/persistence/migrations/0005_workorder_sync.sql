@@ -0,0 +1,3 @@
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS synced_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_workorders_sync ON workorders(synced_at NULLS FIRST) WHERE process_instance_id IS NOT NULL AND status IN ('pending', 'running', 'failed');
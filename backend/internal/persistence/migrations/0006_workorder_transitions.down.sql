@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_workorders_sync;
+CREATE INDEX IF NOT EXISTS idx_workorders_sync ON workorders(synced_at NULLS FIRST) WHERE process_instance_id IS NOT NULL AND status IN ('pending', 'running', 'failed');
+
+DROP TABLE IF EXISTS workorder_transitions;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS workorder_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    workorder_id TEXT NOT NULL REFERENCES workorders(id),
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workorder_transitions_workorder ON workorder_transitions(workorder_id, created_at);
+
+DROP INDEX IF EXISTS idx_workorders_sync;
+CREATE INDEX IF NOT EXISTS idx_workorders_sync ON workorders(synced_at NULLS FIRST) WHERE process_instance_id IS NOT NULL AND status IN ('pending', 'running', 'failed', 'suspended');
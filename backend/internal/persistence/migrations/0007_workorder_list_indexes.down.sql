@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_workorders_created_at_id;
+DROP INDEX IF EXISTS idx_workorders_updated_at_id;
+DROP INDEX IF EXISTS idx_workorders_status_created_at;
+DROP INDEX IF EXISTS idx_workorders_flow_id_created_at;
+DROP INDEX IF EXISTS idx_workorders_assignee_created_at;
+DROP INDEX IF EXISTS idx_workorders_metadata;
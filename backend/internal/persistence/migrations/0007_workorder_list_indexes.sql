@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS idx_workorders_created_at_id ON workorders(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_updated_at_id ON workorders(updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_status_created_at ON workorders(status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_flow_id_created_at ON workorders(flow_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_assignee_created_at ON workorders(assignee, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_metadata ON workorders USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_flows_search_vector;
+DROP INDEX IF EXISTS idx_flows_metadata;
+DROP INDEX IF EXISTS idx_flows_updated_at_id;
+DROP INDEX IF EXISTS idx_flows_created_at_id;
+DROP INDEX IF EXISTS idx_flows_name_id;
+
+ALTER TABLE flows DROP COLUMN IF EXISTS search_vector;
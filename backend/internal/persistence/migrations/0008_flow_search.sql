@@ -0,0 +1,8 @@
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(description, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_flows_search_vector ON flows USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_flows_metadata ON flows USING GIN (metadata jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_flows_updated_at_id ON flows(updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_flows_created_at_id ON flows(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_flows_name_id ON flows(name, id);
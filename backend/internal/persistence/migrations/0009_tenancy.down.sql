@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_flows_tenant_updated_at_id;
+DROP INDEX IF EXISTS idx_flows_tenant_created_at_id;
+DROP INDEX IF EXISTS idx_flows_tenant_name_id;
+DROP INDEX IF EXISTS idx_flow_versions_tenant;
+
+DROP INDEX IF EXISTS idx_workorders_tenant_created_at_id;
+DROP INDEX IF EXISTS idx_workorders_tenant_updated_at_id;
+DROP INDEX IF EXISTS idx_workorders_tenant_status_created_at;
+DROP INDEX IF EXISTS idx_workorders_tenant_flow_id_created_at;
+DROP INDEX IF EXISTS idx_workorders_tenant_assignee_created_at;
+
+ALTER TABLE workorders DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE flow_versions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE flows DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,14 @@
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE flow_versions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_flows_tenant_updated_at_id ON flows(tenant_id, updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_flows_tenant_created_at_id ON flows(tenant_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_flows_tenant_name_id ON flows(tenant_id, name, id);
+CREATE INDEX IF NOT EXISTS idx_flow_versions_tenant ON flow_versions(tenant_id, flow_id, version);
+
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_created_at_id ON workorders(tenant_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_updated_at_id ON workorders(tenant_id, updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_status_created_at ON workorders(tenant_id, status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_flow_id_created_at ON workorders(tenant_id, flow_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_assignee_created_at ON workorders(tenant_id, assignee, created_at DESC, id DESC);
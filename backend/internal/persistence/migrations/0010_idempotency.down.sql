@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS uq_workorders_external_id;
+
+ALTER TABLE workorders DROP COLUMN IF EXISTS request_fingerprint;
+ALTER TABLE workorders DROP COLUMN IF EXISTS external_id;
+
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    tenant_id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    workorder_id TEXT NOT NULL REFERENCES workorders(id),
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (tenant_id, key)
+);
+
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS external_id TEXT;
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS request_fingerprint TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_workorders_external_id ON workorders(tenant_id, flow_id, external_id) WHERE external_id IS NOT NULL;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS bulk_jobs (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    status TEXT NOT NULL,
+    total INTEGER NOT NULL,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_tenant_created_at ON bulk_jobs(tenant_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS bulk_job_items (
+    job_id TEXT NOT NULL REFERENCES bulk_jobs(id) ON DELETE CASCADE,
+    idx INTEGER NOT NULL,
+    workorder_id TEXT,
+    status TEXT NOT NULL,
+    error TEXT,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (job_id, idx)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_job_items_status ON bulk_job_items(job_id, status, idx);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS workorder_assignments;
+
+DROP INDEX IF EXISTS idx_workorders_candidate_users;
+DROP INDEX IF EXISTS idx_workorders_candidate_groups;
+
+ALTER TABLE workorders DROP COLUMN IF EXISTS candidate_groups;
+ALTER TABLE workorders DROP COLUMN IF EXISTS candidate_users;
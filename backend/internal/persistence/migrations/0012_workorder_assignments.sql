@@ -0,0 +1,18 @@
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS candidate_users JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS candidate_groups JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_workorders_candidate_users ON workorders USING GIN (candidate_users);
+CREATE INDEX IF NOT EXISTS idx_workorders_candidate_groups ON workorders USING GIN (candidate_groups);
+
+CREATE TABLE IF NOT EXISTS workorder_assignments (
+    id BIGSERIAL PRIMARY KEY,
+    workorder_id TEXT NOT NULL REFERENCES workorders(id),
+    action TEXT NOT NULL,
+    from_assignee TEXT NOT NULL DEFAULT '',
+    to_assignee TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workorder_assignments_workorder ON workorder_assignments(workorder_id, created_at);
@@ -0,0 +1 @@
+ALTER TABLE workorders DROP COLUMN IF EXISTS current_step;
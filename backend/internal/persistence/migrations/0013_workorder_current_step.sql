@@ -0,0 +1 @@
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS current_step TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS workorder_comments (
+    id TEXT PRIMARY KEY,
+    workorder_id TEXT NOT NULL REFERENCES workorders(id),
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_workorder_comments_workorder ON workorder_comments(workorder_id, created_at) WHERE deleted_at IS NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS workorder_attachments (
+    id TEXT PRIMARY KEY,
+    workorder_id TEXT NOT NULL REFERENCES workorders(id),
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    checksum TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    uploaded_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workorder_attachments_workorder ON workorder_attachments(workorder_id, created_at);
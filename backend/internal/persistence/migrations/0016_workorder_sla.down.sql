@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS sla_policies;
+
+DROP INDEX IF EXISTS idx_workorders_tenant_sla_state_created_at;
+DROP INDEX IF EXISTS idx_workorders_tenant_due_at;
+DROP INDEX IF EXISTS idx_workorders_sla_open;
+
+ALTER TABLE workorders DROP COLUMN IF EXISTS sla_warning_at;
+ALTER TABLE workorders DROP COLUMN IF EXISTS due_at;
+ALTER TABLE workorders DROP COLUMN IF EXISTS sla_state;
+ALTER TABLE workorders DROP COLUMN IF EXISTS priority;
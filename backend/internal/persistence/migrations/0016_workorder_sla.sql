@@ -0,0 +1,20 @@
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'normal';
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS sla_state TEXT NOT NULL DEFAULT 'none';
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS sla_warning_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_sla_state_created_at ON workorders(tenant_id, sla_state, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_due_at ON workorders(tenant_id, due_at);
+CREATE INDEX IF NOT EXISTS idx_workorders_sla_open ON workorders(sla_warning_at) WHERE sla_state IN ('on_track', 'at_risk');
+
+CREATE TABLE IF NOT EXISTS sla_policies (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    flow_id TEXT NOT NULL DEFAULT '',
+    priority TEXT NOT NULL DEFAULT '',
+    resolution_seconds BIGINT NOT NULL,
+    warning_seconds BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT uq_sla_policies_scope UNIQUE (tenant_id, flow_id, priority)
+);
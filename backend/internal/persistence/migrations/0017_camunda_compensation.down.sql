@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_workorders_start_due;
+DROP INDEX IF EXISTS idx_workorders_tenant_start_status_created_at;
+
+ALTER TABLE workorders DROP COLUMN IF EXISTS next_start_at;
+ALTER TABLE workorders DROP COLUMN IF EXISTS start_error;
+ALTER TABLE workorders DROP COLUMN IF EXISTS start_attempts;
+ALTER TABLE workorders DROP COLUMN IF EXISTS start_status;
+
+DROP INDEX IF EXISTS idx_flows_deploy_due;
+DROP INDEX IF EXISTS idx_flows_tenant_deploy_status;
+
+ALTER TABLE flows DROP COLUMN IF EXISTS deployed_at;
+ALTER TABLE flows DROP COLUMN IF EXISTS next_deploy_at;
+ALTER TABLE flows DROP COLUMN IF EXISTS deploy_error;
+ALTER TABLE flows DROP COLUMN IF EXISTS deploy_attempts;
+ALTER TABLE flows DROP COLUMN IF EXISTS deploy_status;
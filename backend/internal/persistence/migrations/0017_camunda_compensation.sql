@@ -0,0 +1,20 @@
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS deploy_status TEXT NOT NULL DEFAULT 'deployed';
+ALTER TABLE flows ALTER COLUMN deploy_status SET DEFAULT 'pending';
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS deploy_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS deploy_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS next_deploy_at TIMESTAMPTZ;
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS deployed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_flows_tenant_deploy_status ON flows(tenant_id, deploy_status);
+CREATE INDEX IF NOT EXISTS idx_flows_deploy_due ON flows(next_deploy_at) WHERE deploy_status = 'pending';
+
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS start_status TEXT NOT NULL DEFAULT 'none';
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS start_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS start_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE workorders ADD COLUMN IF NOT EXISTS next_start_at TIMESTAMPTZ;
+
+UPDATE workorders SET start_status = 'started' WHERE process_instance_id IS NOT NULL;
+UPDATE workorders SET start_status = 'pending', next_start_at = created_at WHERE process_instance_id IS NULL AND status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_start_status_created_at ON workorders(tenant_id, start_status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workorders_start_due ON workorders(next_start_at) WHERE start_status = 'pending';
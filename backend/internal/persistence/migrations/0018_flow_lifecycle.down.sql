@@ -0,0 +1,11 @@
+UPDATE flows SET deploy_status = 'deployed' WHERE deploy_status = 'none';
+
+DROP INDEX IF EXISTS idx_flows_tenant_status_updated_at;
+
+ALTER TABLE flow_versions DROP COLUMN IF EXISTS published_at;
+ALTER TABLE flow_versions DROP COLUMN IF EXISTS process_definition_id;
+ALTER TABLE flow_versions DROP COLUMN IF EXISTS deployment_id;
+
+ALTER TABLE flows ALTER COLUMN deploy_status SET DEFAULT 'pending';
+ALTER TABLE flows DROP COLUMN IF EXISTS published_version;
+ALTER TABLE flows DROP COLUMN IF EXISTS status;
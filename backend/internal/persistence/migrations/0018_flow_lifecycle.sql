@@ -0,0 +1,16 @@
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE flows ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS published_version INTEGER;
+ALTER TABLE flows ALTER COLUMN deploy_status SET DEFAULT 'none';
+
+UPDATE flows SET published_version = version WHERE published_version IS NULL AND status = 'published';
+
+ALTER TABLE flow_versions ADD COLUMN IF NOT EXISTS deployment_id TEXT;
+ALTER TABLE flow_versions ADD COLUMN IF NOT EXISTS process_definition_id TEXT;
+ALTER TABLE flow_versions ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+UPDATE flow_versions v SET published_at = v.created_at
+FROM flows f
+WHERE f.id = v.flow_id AND f.published_version = v.version AND v.published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_flows_tenant_status_updated_at ON flows(tenant_id, status, updated_at DESC, id DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workorders_tenant_flow_version;
+DROP TABLE IF EXISTS flow_migration_items;
+DROP TABLE IF EXISTS flow_migrations;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS flow_migrations (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    flow_id TEXT NOT NULL,
+    source_version INTEGER NOT NULL,
+    target_version INTEGER NOT NULL,
+    source_definition_id TEXT NOT NULL,
+    target_definition_id TEXT NOT NULL,
+    instructions JSONB NOT NULL DEFAULT '[]'::jsonb,
+    status TEXT NOT NULL,
+    total INTEGER NOT NULL,
+    migrated INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_flow_migrations_tenant_flow_created_at ON flow_migrations(tenant_id, flow_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS flow_migration_items (
+    migration_id TEXT NOT NULL REFERENCES flow_migrations(id) ON DELETE CASCADE,
+    idx INTEGER NOT NULL,
+    workorder_id TEXT NOT NULL,
+    process_instance_id TEXT NOT NULL,
+    current_step TEXT NOT NULL DEFAULT '',
+    batch INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (migration_id, idx)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flow_migration_items_status ON flow_migration_items(migration_id, status, idx);
+
+CREATE INDEX IF NOT EXISTS idx_workorders_tenant_flow_version ON workorders(tenant_id, flow_id, flow_version);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_bulk_job_items_pending;
+
+ALTER TABLE bulk_job_items DROP COLUMN IF EXISTS claimed_at;
+ALTER TABLE bulk_job_items DROP COLUMN IF EXISTS input;
+
+ALTER TABLE bulk_jobs DROP COLUMN IF EXISTS assignee;
+ALTER TABLE bulk_jobs DROP COLUMN IF EXISTS reason;
@@ -0,0 +1,7 @@
+ALTER TABLE bulk_jobs ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE bulk_jobs ADD COLUMN IF NOT EXISTS assignee TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE bulk_job_items ADD COLUMN IF NOT EXISTS input JSONB;
+ALTER TABLE bulk_job_items ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_bulk_job_items_pending ON bulk_job_items(claimed_at NULLS FIRST) WHERE status = 'pending';
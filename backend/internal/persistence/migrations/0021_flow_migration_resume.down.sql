@@ -0,0 +1,5 @@
+UPDATE flow_migrations SET status = 'completed' WHERE status IN ('failed', 'partially_failed');
+
+DROP INDEX IF EXISTS idx_flow_migrations_active;
+
+ALTER TABLE flow_migrations DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1,6 @@
+ALTER TABLE flow_migrations ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_flow_migrations_active ON flow_migrations(created_at) WHERE status IN ('queued', 'running');
+
+UPDATE flow_migrations SET status = CASE WHEN migrated = 0 THEN 'failed' ELSE 'partially_failed' END
+WHERE status = 'completed' AND failed > 0;
@@ -0,0 +1,3 @@
+DELETE FROM workorder_assignments WHERE action = 'candidates';
+
+ALTER TABLE workorder_assignments DROP COLUMN IF EXISTS candidates;
@@ -0,0 +1 @@
+ALTER TABLE workorder_assignments ADD COLUMN IF NOT EXISTS candidates JSONB;
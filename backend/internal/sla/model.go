@@ -0,0 +1,88 @@
+package sla
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+const (
+	MetadataResolution = "sla.resolution"
+	MetadataWarning    = "sla.warning"
+)
+
+const DefaultWarningRatio = 0.2
+
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch v := raw.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(time.Duration(v * float64(time.Second)))
+	default:
+		return fmt.Errorf("duration must be a string such as \"4h\" or a number of seconds")
+	}
+	return nil
+}
+
+func (d *Duration) Scan(src any) error {
+	seconds, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("scan duration: unexpected type %T", src)
+	}
+	*d = Duration(time.Duration(seconds) * time.Second)
+	return nil
+}
+
+func (d Duration) Value() (driver.Value, error) {
+	return int64(time.Duration(d) / time.Second), nil
+}
+
+type Policy struct {
+	ID         string             `json:"id" db:"id"`
+	TenantID   string             `json:"tenantId" db:"tenant_id"`
+	FlowID     string             `json:"flowId,omitempty" db:"flow_id"`
+	Priority   workorder.Priority `json:"priority,omitempty" db:"priority"`
+	Resolution Duration           `json:"resolution" db:"resolution_seconds"`
+	Warning    Duration           `json:"warning" db:"warning_seconds"`
+	CreatedAt  time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time          `json:"updatedAt" db:"updated_at"`
+}
+
+type PolicyInput struct {
+	FlowID     string
+	Priority   workorder.Priority
+	Resolution time.Duration
+	Warning    time.Duration
+}
+
+func (p Policy) target() workorder.SLATarget {
+	return newTarget(time.Duration(p.Resolution), time.Duration(p.Warning))
+}
+
+func newTarget(resolution, warning time.Duration) workorder.SLATarget {
+	if warning <= 0 {
+		warning = time.Duration(float64(resolution) * DefaultWarningRatio)
+	}
+	if warning > resolution {
+		warning = resolution
+	}
+	return workorder.SLATarget{Resolution: resolution, WarnBefore: warning}
+}
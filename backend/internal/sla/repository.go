@@ -0,0 +1,138 @@
+package sla
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+const policyColumns = `id, tenant_id, flow_id, priority, resolution_seconds, warning_seconds, created_at, updated_at`
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) List(ctx context.Context) ([]Policy, error) {
+	const query = `SELECT ` + policyColumns + ` FROM sla_policies WHERE tenant_id = $1 ORDER BY flow_id, priority`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Policy{}
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &result, query, tenantID); err != nil {
+		return nil, fmt.Errorf("list sla policies: %w", err)
+	}
+	return result, nil
+}
+
+func (r *repository) Get(ctx context.Context, id string) (Policy, error) {
+	const query = `SELECT ` + policyColumns + ` FROM sla_policies WHERE id = $1 AND tenant_id = $2`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	var p Policy
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &p, query, id, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Policy{}, sqlErrNotFound
+		}
+		return Policy{}, fmt.Errorf("get sla policy: %w", err)
+	}
+	return p, nil
+}
+
+func (r *repository) Match(ctx context.Context, flowID string, priority workorder.Priority) ([]Policy, error) {
+	const query = `SELECT ` + policyColumns + ` FROM sla_policies
+WHERE tenant_id = $1 AND flow_id IN ($2, '') AND priority IN ($3, '')`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Policy
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &result, query, tenantID, flowID, priority); err != nil {
+		return nil, fmt.Errorf("match sla policies: %w", err)
+	}
+	return result, nil
+}
+
+func (r *repository) Create(ctx context.Context, p Policy) (Policy, error) {
+	const query = `INSERT INTO sla_policies (` + policyColumns + `) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	p.TenantID = tenantID
+	_, err = persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, p.ID, p.TenantID, p.FlowID, p.Priority, p.Resolution, p.Warning, p.CreatedAt, p.UpdatedAt)
+	if err != nil {
+		if persistence.IsUniqueViolation(err, "uq_sla_policies_scope") {
+			return Policy{}, sqlErrDuplicateScope
+		}
+		return Policy{}, fmt.Errorf("insert sla policy: %w", err)
+	}
+	return p, nil
+}
+
+func (r *repository) Update(ctx context.Context, p Policy) (Policy, error) {
+	const query = `UPDATE sla_policies SET flow_id = $2, priority = $3, resolution_seconds = $4, warning_seconds = $5, updated_at = $6
+WHERE id = $1 AND tenant_id = $7
+RETURNING ` + policyColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	var updated Policy
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &updated, query, p.ID, p.FlowID, p.Priority, p.Resolution, p.Warning, p.UpdatedAt, tenantID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return Policy{}, sqlErrNotFound
+		case persistence.IsUniqueViolation(err, "uq_sla_policies_scope"):
+			return Policy{}, sqlErrDuplicateScope
+		}
+		return Policy{}, fmt.Errorf("update sla policy: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *repository) Delete(ctx context.Context, id string) error {
+	const query = `DELETE FROM sla_policies WHERE id = $1 AND tenant_id = $2`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete sla policy: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sqlErrNotFound
+	}
+	return nil
+}
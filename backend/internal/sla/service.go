@@ -0,0 +1,250 @@
+package sla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Service interface {
+	List(ctx context.Context) ([]Policy, error)
+	Get(ctx context.Context, id string) (Policy, error)
+	Create(ctx context.Context, input PolicyInput) (Policy, error)
+	Update(ctx context.Context, id string, input PolicyInput) (Policy, error)
+	Delete(ctx context.Context, id string) error
+	Resolve(ctx context.Context, flow workorder.FlowSummary, priority workorder.Priority) (workorder.SLATarget, bool, error)
+}
+
+type Repository interface {
+	List(ctx context.Context) ([]Policy, error)
+	Get(ctx context.Context, id string) (Policy, error)
+	Match(ctx context.Context, flowID string, priority workorder.Priority) ([]Policy, error)
+	Create(ctx context.Context, p Policy) (Policy, error)
+	Update(ctx context.Context, p Policy) (Policy, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+func IsInvalid(err error) bool {
+	var target *ValidationError
+	return errors.As(err, &target)
+}
+
+type ConflictError struct {
+	FlowID   string
+	Priority workorder.Priority
+}
+
+func (e *ConflictError) Error() string {
+	flowID, priority := e.FlowID, string(e.Priority)
+	if flowID == "" {
+		flowID = "*"
+	}
+	if priority == "" {
+		priority = "*"
+	}
+	return fmt.Sprintf("an sla policy for flow %s and priority %s already exists", flowID, priority)
+}
+
+func IsConflict(err error) bool {
+	var target *ConflictError
+	return errors.As(err, &target)
+}
+
+type notFoundError struct{ id string }
+
+func (e notFoundError) Error() string { return fmt.Sprintf("sla policy %s not found", e.id) }
+
+func (notFoundError) NotFound() {}
+
+func IsNotFound(err error) bool {
+	var target interface{ NotFound() }
+	return errors.As(err, &target)
+}
+
+var (
+	sqlErrNotFound       = errors.New("sla policy not found")
+	sqlErrDuplicateScope = errors.New("sla policy scope already exists")
+)
+
+type service struct {
+	repo  Repository
+	flows workorder.FlowReader
+}
+
+func NewService(repo Repository, flows workorder.FlowReader) Service {
+	return &service{repo: repo, flows: flows}
+}
+
+func (s *service) List(ctx context.Context) ([]Policy, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *service) Get(ctx context.Context, id string) (Policy, error) {
+	p, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Policy{}, notFoundError{id: id}
+		}
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+func (s *service) Create(ctx context.Context, input PolicyInput) (Policy, error) {
+	input, err := s.validate(ctx, input)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	now := time.Now().UTC()
+	created, err := s.repo.Create(ctx, Policy{
+		ID:         uuid.NewString(),
+		FlowID:     input.FlowID,
+		Priority:   input.Priority,
+		Resolution: Duration(input.Resolution),
+		Warning:    Duration(input.Warning),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if errors.Is(err, sqlErrDuplicateScope) {
+		return Policy{}, &ConflictError{FlowID: input.FlowID, Priority: input.Priority}
+	}
+	return created, err
+}
+
+func (s *service) Update(ctx context.Context, id string, input PolicyInput) (Policy, error) {
+	input, err := s.validate(ctx, input)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	updated, err := s.repo.Update(ctx, Policy{
+		ID:         id,
+		FlowID:     input.FlowID,
+		Priority:   input.Priority,
+		Resolution: Duration(input.Resolution),
+		Warning:    Duration(input.Warning),
+		UpdatedAt:  time.Now().UTC(),
+	})
+	switch {
+	case errors.Is(err, sqlErrNotFound):
+		return Policy{}, notFoundError{id: id}
+	case errors.Is(err, sqlErrDuplicateScope):
+		return Policy{}, &ConflictError{FlowID: input.FlowID, Priority: input.Priority}
+	}
+	return updated, err
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return notFoundError{id: id}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) validate(ctx context.Context, input PolicyInput) (PolicyInput, error) {
+	input.FlowID = strings.TrimSpace(input.FlowID)
+	input.Resolution = input.Resolution.Truncate(time.Second)
+	input.Warning = input.Warning.Truncate(time.Second)
+
+	switch {
+	case input.Priority != "" && !input.Priority.Valid():
+		return PolicyInput{}, &ValidationError{Field: "priority", Message: fmt.Sprintf("unknown priority %q", input.Priority)}
+	case input.Resolution <= 0:
+		return PolicyInput{}, &ValidationError{Field: "resolution", Message: "must be at least one second"}
+	case input.Warning < 0:
+		return PolicyInput{}, &ValidationError{Field: "warning", Message: "must not be negative"}
+	case input.Warning >= input.Resolution:
+		return PolicyInput{}, &ValidationError{Field: "warning", Message: "must be shorter than the resolution time"}
+	}
+
+	if input.FlowID != "" {
+		if _, err := s.flows.Get(ctx, input.FlowID); err != nil {
+			return PolicyInput{}, fmt.Errorf("load flow: %w", err)
+		}
+	}
+	return input, nil
+}
+
+func (s *service) Resolve(ctx context.Context, flow workorder.FlowSummary, priority workorder.Priority) (workorder.SLATarget, bool, error) {
+	policies, err := s.repo.Match(ctx, flow.ID, priority)
+	if err != nil {
+		return workorder.SLATarget{}, false, err
+	}
+
+	var (
+		best  Policy
+		found bool
+	)
+	for _, p := range policies {
+		if !found || specificity(p) > specificity(best) {
+			best, found = p, true
+		}
+	}
+
+	if found && best.FlowID != "" {
+		return best.target(), true, nil
+	}
+	if target, ok := fromMetadata(flow, priority); ok {
+		return target, true, nil
+	}
+	if found {
+		return best.target(), true, nil
+	}
+	return workorder.SLATarget{}, false, nil
+}
+
+func specificity(p Policy) int {
+	score := 0
+	if p.FlowID != "" {
+		score += 2
+	}
+	if p.Priority != "" {
+		score++
+	}
+	return score
+}
+
+func fromMetadata(flow workorder.FlowSummary, priority workorder.Priority) (workorder.SLATarget, bool) {
+	resolution, ok := metadataDuration(flow, MetadataResolution, priority)
+	if !ok || resolution <= 0 {
+		return workorder.SLATarget{}, false
+	}
+	warning, _ := metadataDuration(flow, MetadataWarning, priority)
+	return newTarget(resolution, warning), true
+}
+
+func metadataDuration(flow workorder.FlowSummary, key string, priority workorder.Priority) (time.Duration, bool) {
+	for _, k := range []string{key + "." + string(priority), key} {
+		raw, ok := flow.Metadata[k]
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			log.Printf("sla: flow %s metadata %s: invalid duration %q", flow.ID, k, raw)
+			continue
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,88 @@
+package task
+
+import (
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Scope string
+
+const (
+	ScopeMine       Scope = "mine"
+	ScopeAssigned   Scope = "assigned"
+	ScopeCandidate  Scope = "candidate"
+	ScopeUnassigned Scope = "unassigned"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+type Task struct {
+	ID                string        `json:"id"`
+	Name              string        `json:"name"`
+	ActivityID        string        `json:"activityId"`
+	Assignee          string        `json:"assignee,omitempty"`
+	ProcessInstanceID string        `json:"processInstanceId"`
+	TenantID          string        `json:"tenantId,omitempty"`
+	Priority          int           `json:"priority"`
+	CreatedAt         time.Time     `json:"createdAt"`
+	Due               *time.Time    `json:"due,omitempty"`
+	WorkOrder         *WorkOrderRef `json:"workOrder,omitempty"`
+	Form              *bpmn.Form    `json:"form,omitempty"`
+}
+
+type WorkOrderRef struct {
+	ID          string           `json:"id"`
+	Title       string           `json:"title"`
+	FlowID      string           `json:"flowId"`
+	FlowVersion int              `json:"flowVersion"`
+	Status      workorder.Status `json:"status"`
+	Assignee    string           `json:"assignee"`
+	CurrentStep string           `json:"currentStep"`
+}
+
+func refOf(wo workorder.WorkOrder) *WorkOrderRef {
+	return &WorkOrderRef{
+		ID:          wo.ID,
+		Title:       wo.Title,
+		FlowID:      wo.FlowID,
+		FlowVersion: wo.FlowVersion,
+		Status:      wo.Status,
+		Assignee:    wo.Assignee,
+		CurrentStep: wo.CurrentStep,
+	}
+}
+
+type ListFilter struct {
+	Scope    Scope
+	Assignee string
+	Group    string
+	Limit    int
+	Offset   int
+}
+
+type Query struct {
+	TenantID        string
+	Assignee        string
+	CandidateUser   string
+	CandidateGroups []string
+	IncludeAssigned bool
+	Unassigned      bool
+	AnyOf           bool
+	FirstResult     int
+	MaxResults      int
+}
+
+type Page struct {
+	Items      []Task `json:"items"`
+	NextOffset int    `json:"nextOffset,omitempty"`
+}
+
+type Completion struct {
+	Task      Task                `json:"task"`
+	WorkOrder workorder.WorkOrder `json:"workOrder"`
+}
@@ -0,0 +1,313 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/bpmn"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/flow"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/workorder"
+)
+
+type Service interface {
+	List(ctx context.Context, filter ListFilter) (Page, error)
+	Get(ctx context.Context, id string) (Task, error)
+	Claim(ctx context.Context, id string) (Task, error)
+	Complete(ctx context.Context, id string, variables map[string]any) (Completion, error)
+}
+
+type Runtime interface {
+	ListTasks(ctx context.Context, q Query) ([]Task, error)
+	GetTask(ctx context.Context, id string) (Task, bool, error)
+	CompleteTask(ctx context.Context, id string, variables map[string]any) error
+}
+
+type WorkOrders interface {
+	ByProcessInstances(ctx context.Context, instanceIDs []string) (map[string]workorder.WorkOrder, error)
+	Claim(ctx context.Context, id string) (workorder.WorkOrder, error)
+	Refresh(ctx context.Context, id string) (workorder.WorkOrder, error)
+}
+
+type FlowVersions interface {
+	GetVersion(ctx context.Context, id string, version int) (flow.Snapshot, error)
+}
+
+type QueryError struct {
+	Field   string
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+func IsInvalidQuery(err error) bool {
+	var target *QueryError
+	return errors.As(err, &target)
+}
+
+type FormError struct {
+	TaskID   string
+	Problems []bpmn.FieldError
+}
+
+func (e *FormError) Error() string {
+	messages := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		messages = append(messages, p.Field+" "+p.Message)
+	}
+	return fmt.Sprintf("task %s form is invalid: %s", e.TaskID, strings.Join(messages, "; "))
+}
+
+func AsFormError(err error) (*FormError, bool) {
+	var target *FormError
+	if errors.As(err, &target) {
+		return target, true
+	}
+	return nil, false
+}
+
+type NotAssigneeError struct {
+	ID       string
+	Assignee string
+}
+
+func (e *NotAssigneeError) Error() string {
+	return fmt.Sprintf("task %s is assigned to %s", e.ID, e.Assignee)
+}
+
+func IsNotAssignee(err error) bool {
+	var target *NotAssigneeError
+	return errors.As(err, &target)
+}
+
+type notFoundError struct{ id string }
+
+func (e notFoundError) Error() string { return fmt.Sprintf("task %s not found", e.id) }
+
+func (notFoundError) NotFound() {}
+
+func IsNotFound(err error) bool {
+	var target interface{ NotFound() }
+	return errors.As(err, &target)
+}
+
+type service struct {
+	runtime    Runtime
+	workorders WorkOrders
+	flows      FlowVersions
+}
+
+func NewService(runtime Runtime, workorders WorkOrders, flows FlowVersions) Service {
+	return &service{runtime: runtime, workorders: workorders, flows: flows}
+}
+
+func (f ListFilter) normalize() (ListFilter, error) {
+	if f.Scope == "" {
+		f.Scope = ScopeMine
+	}
+	switch f.Scope {
+	case ScopeMine, ScopeAssigned, ScopeCandidate, ScopeUnassigned:
+	default:
+		return ListFilter{}, &QueryError{Field: "scope", Message: fmt.Sprintf("unsupported scope %q", f.Scope)}
+	}
+	if f.Offset < 0 {
+		return ListFilter{}, &QueryError{Field: "offset", Message: "must not be negative"}
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+	return f, nil
+}
+
+func (s *service) List(ctx context.Context, filter ListFilter) (Page, error) {
+	filter, err := filter.normalize()
+	if err != nil {
+		return Page{}, err
+	}
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+
+	actor := workorder.ActorFromContext(ctx)
+	groups := workorder.GroupsFromContext(ctx)
+	q := Query{TenantID: tenantID, FirstResult: filter.Offset, MaxResults: filter.Limit + 1}
+	switch {
+	case filter.Assignee != "":
+		q.Assignee = filter.Assignee
+	case filter.Group != "":
+		q.CandidateGroups = []string{filter.Group}
+		q.IncludeAssigned = true
+	case filter.Scope == ScopeAssigned:
+		q.Assignee = actor
+	case filter.Scope == ScopeCandidate:
+		q.CandidateUser = actor
+		q.CandidateGroups = groups
+		q.AnyOf = len(groups) > 0
+	case filter.Scope == ScopeUnassigned:
+		q.Unassigned = true
+	default:
+		q.Assignee = actor
+		q.CandidateUser = actor
+		q.CandidateGroups = groups
+		q.AnyOf = true
+	}
+
+	tasks, err := s.runtime.ListTasks(ctx, q)
+	if err != nil {
+		return Page{}, fmt.Errorf("list tasks: %w", err)
+	}
+
+	page := Page{Items: []Task{}}
+	if len(tasks) > filter.Limit {
+		tasks = tasks[:filter.Limit]
+		page.NextOffset = filter.Offset + filter.Limit
+	}
+
+	instanceIDs := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		instanceIDs = append(instanceIDs, t.ProcessInstanceID)
+	}
+	orders, err := s.workorders.ByProcessInstances(ctx, instanceIDs)
+	if err != nil {
+		return Page{}, err
+	}
+	for _, t := range tasks {
+		wo, ok := orders[t.ProcessInstanceID]
+		if !ok {
+			continue
+		}
+		t.WorkOrder = refOf(wo)
+		page.Items = append(page.Items, t)
+	}
+	return page, nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (Task, error) {
+	t, wo, err := s.load(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	form, err := s.form(ctx, wo, t.ActivityID)
+	if err != nil {
+		return Task{}, err
+	}
+	t.Form = form
+	return t, nil
+}
+
+func (s *service) Claim(ctx context.Context, id string) (Task, error) {
+	t, wo, err := s.load(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if actor := workorder.ActorFromContext(ctx); t.Assignee != "" && t.Assignee != actor {
+		return Task{}, &NotAssigneeError{ID: t.ID, Assignee: t.Assignee}
+	}
+	if _, err := s.workorders.Claim(ctx, wo.ID); err != nil {
+		return Task{}, err
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *service) Complete(ctx context.Context, id string, variables map[string]any) (Completion, error) {
+	t, wo, err := s.load(ctx, id)
+	if err != nil {
+		return Completion{}, err
+	}
+
+	actor := workorder.ActorFromContext(ctx)
+	if t.Assignee != "" && t.Assignee != actor {
+		return Completion{}, &NotAssigneeError{ID: t.ID, Assignee: t.Assignee}
+	}
+
+	form, err := s.form(ctx, wo, t.ActivityID)
+	if err != nil {
+		return Completion{}, err
+	}
+	if variables == nil {
+		variables = map[string]any{}
+	}
+	if form != nil {
+		values, problems := form.Validate(variables)
+		if len(problems) > 0 {
+			return Completion{}, &FormError{TaskID: t.ID, Problems: problems}
+		}
+		variables = values
+	}
+	t.Form = form
+
+	if t.Assignee == "" && wo.Assignee != actor {
+		if _, err := s.workorders.Claim(ctx, wo.ID); err != nil {
+			return Completion{}, err
+		}
+		t.Assignee = actor
+	}
+
+	if err := s.runtime.CompleteTask(ctx, t.ID, variables); err != nil {
+		return Completion{}, fmt.Errorf("complete task: %w", err)
+	}
+
+	refreshed, err := s.workorders.Refresh(ctx, wo.ID)
+	if err != nil {
+		return Completion{}, fmt.Errorf("refresh workorder: %w", err)
+	}
+	t.WorkOrder = refOf(refreshed)
+	return Completion{Task: t, WorkOrder: refreshed}, nil
+}
+
+func (s *service) load(ctx context.Context, id string) (Task, workorder.WorkOrder, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Task{}, workorder.WorkOrder{}, err
+	}
+
+	t, found, err := s.runtime.GetTask(ctx, id)
+	if err != nil {
+		return Task{}, workorder.WorkOrder{}, fmt.Errorf("get task: %w", err)
+	}
+	if !found || (t.TenantID != "" && t.TenantID != tenantID) {
+		return Task{}, workorder.WorkOrder{}, notFoundError{id: id}
+	}
+
+	orders, err := s.workorders.ByProcessInstances(ctx, []string{t.ProcessInstanceID})
+	if err != nil {
+		return Task{}, workorder.WorkOrder{}, err
+	}
+	wo, ok := orders[t.ProcessInstanceID]
+	if !ok {
+		return Task{}, workorder.WorkOrder{}, notFoundError{id: id}
+	}
+	t.WorkOrder = refOf(wo)
+	return t, wo, nil
+}
+
+func (s *service) form(ctx context.Context, wo workorder.WorkOrder, activityID string) (*bpmn.Form, error) {
+	snapshot, err := s.flows.GetVersion(ctx, wo.FlowID, wo.FlowVersion)
+	if err != nil {
+		return nil, fmt.Errorf("load flow %s version %d: %w", wo.FlowID, wo.FlowVersion, err)
+	}
+
+	graph, _ := bpmn.Parse(snapshot.Definition)
+	for _, n := range graph.Nodes {
+		if bpmn.ElementID(n.ID) != activityID {
+			continue
+		}
+		form, ok, err := n.Form()
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.ID, err)
+		}
+		if !ok {
+			return nil, nil
+		}
+		return &form, nil
+	}
+	return nil, nil
+}
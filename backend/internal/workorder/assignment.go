@@ -0,0 +1,315 @@
+package workorder
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+type AssignmentAction string
+
+const (
+	AssignmentAssign     AssignmentAction = "assign"
+	AssignmentReassign   AssignmentAction = "reassign"
+	AssignmentClaim      AssignmentAction = "claim"
+	AssignmentRelease    AssignmentAction = "release"
+	AssignmentSync       AssignmentAction = "sync"
+	AssignmentCandidates AssignmentAction = "candidates"
+)
+
+type Assignment struct {
+	ID           int64            `json:"id" db:"id"`
+	WorkOrderID  string           `json:"workOrderId" db:"workorder_id"`
+	Action       AssignmentAction `json:"action" db:"action"`
+	FromAssignee string           `json:"from" db:"from_assignee"`
+	ToAssignee   string           `json:"to" db:"to_assignee"`
+	Reason       string           `json:"reason" db:"reason"`
+	Candidates   *Candidates      `json:"candidates,omitempty" db:"candidates"`
+	Actor        string           `json:"actor" db:"actor"`
+	CreatedAt    time.Time        `json:"createdAt" db:"created_at"`
+}
+
+type Candidates struct {
+	Users  []string `json:"users"`
+	Groups []string `json:"groups"`
+}
+
+func (c *Candidates) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("scan candidates: unexpected type %T", src)
+	}
+	return json.Unmarshal(raw, c)
+}
+
+func (c Candidates) Value() (driver.Value, error) {
+	if c.Users == nil {
+		c.Users = []string{}
+	}
+	if c.Groups == nil {
+		c.Groups = []string{}
+	}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+type TaskAssignment struct {
+	Assignee   string
+	Candidates Candidates
+}
+
+type AssignmentError struct {
+	ID      string
+	Current string
+}
+
+func (e *AssignmentError) Error() string {
+	return fmt.Sprintf("workorder %s is already assigned to %s", e.ID, e.Current)
+}
+
+func IsAssignmentConflict(err error) bool {
+	var target *AssignmentError
+	return errors.As(err, &target)
+}
+
+type TerminalStateError struct {
+	ID     string
+	Status Status
+}
+
+func (e *TerminalStateError) Error() string {
+	return fmt.Sprintf("workorder %s is %s and can no longer be changed", e.ID, e.Status)
+}
+
+func IsTerminalState(err error) bool {
+	var target *TerminalStateError
+	return errors.As(err, &target)
+}
+
+type NotCandidateError struct {
+	ID    string
+	Actor string
+}
+
+func (e *NotCandidateError) Error() string {
+	return fmt.Sprintf("%s is not a candidate for workorder %s", e.Actor, e.ID)
+}
+
+func IsNotCandidate(err error) bool {
+	var target *NotCandidateError
+	return errors.As(err, &target)
+}
+
+type groupsKey struct{}
+
+func WithGroups(ctx context.Context, groups []string) context.Context {
+	return context.WithValue(ctx, groupsKey{}, groups)
+}
+
+func GroupsFromContext(ctx context.Context) []string {
+	groups, _ := ctx.Value(groupsKey{}).([]string)
+	return groups
+}
+
+func (wo WorkOrder) IsCandidate(user string, groups []string) bool {
+	if len(wo.CandidateUsers) == 0 && len(wo.CandidateGroups) == 0 {
+		return true
+	}
+	for _, candidate := range wo.CandidateUsers {
+		if candidate == user {
+			return true
+		}
+	}
+	for _, candidate := range wo.CandidateGroups {
+		for _, group := range groups {
+			if candidate == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *service) Assign(ctx context.Context, id, assignee, reason string) (WorkOrder, error) {
+	wo, err := s.assignable(ctx, id)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	if wo.Assignee == assignee {
+		return wo, nil
+	}
+
+	action := AssignmentReassign
+	switch {
+	case wo.Assignee == "":
+		action = AssignmentAssign
+	case assignee == "":
+		action = AssignmentRelease
+	}
+	return s.changeAssignee(ctx, wo, assignee, action, reason)
+}
+
+func (s *service) Claim(ctx context.Context, id string) (WorkOrder, error) {
+	wo, err := s.assignable(ctx, id)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	actor := ActorFromContext(ctx)
+	if wo.Assignee == actor {
+		return wo, nil
+	}
+	if wo.Assignee != "" {
+		return WorkOrder{}, &AssignmentError{ID: wo.ID, Current: wo.Assignee}
+	}
+	if !wo.IsCandidate(actor, GroupsFromContext(ctx)) {
+		return WorkOrder{}, &NotCandidateError{ID: wo.ID, Actor: actor}
+	}
+	return s.changeAssignee(ctx, wo, actor, AssignmentClaim, "")
+}
+
+func (s *service) Release(ctx context.Context, id, reason string) (WorkOrder, error) {
+	wo, err := s.assignable(ctx, id)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	if wo.Assignee == "" {
+		return wo, nil
+	}
+	if actor := ActorFromContext(ctx); wo.Assignee != actor {
+		return WorkOrder{}, &AssignmentError{ID: wo.ID, Current: wo.Assignee}
+	}
+	return s.changeAssignee(ctx, wo, "", AssignmentRelease, reason)
+}
+
+func (s *service) SetCandidates(ctx context.Context, id string, candidates Candidates) (WorkOrder, error) {
+	wo, err := s.assignable(ctx, id)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	var updated WorkOrder
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = s.repo.UpdateCandidates(ctx, id, candidates)
+		if err != nil {
+			if errors.Is(err, sqlErrNotFound) {
+				return notFoundError{id: id}
+			}
+			return err
+		}
+
+		return s.repo.RecordAssignment(ctx, Assignment{
+			WorkOrderID:  id,
+			Action:       AssignmentCandidates,
+			FromAssignee: wo.Assignee,
+			ToAssignee:   updated.Assignee,
+			Candidates:   &Candidates{Users: updated.CandidateUsers, Groups: updated.CandidateGroups},
+			Actor:        ActorFromContext(ctx),
+			CreatedAt:    updated.UpdatedAt,
+		})
+	})
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	if err := s.syncTasks(ctx, updated); err != nil {
+		log.Printf("workorder %s: %v", updated.ID, err)
+	}
+	return updated, nil
+}
+
+func (s *service) Assignments(ctx context.Context, id string) ([]Assignment, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAssignments(ctx, id)
+}
+
+func (s *service) assignable(ctx context.Context, id string) (WorkOrder, error) {
+	wo, err := s.Get(ctx, id)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	if wo.Status.Terminal() {
+		return WorkOrder{}, &TerminalStateError{ID: wo.ID, Status: wo.Status}
+	}
+	return wo, nil
+}
+
+func (s *service) changeAssignee(ctx context.Context, wo WorkOrder, assignee string, action AssignmentAction, reason string) (WorkOrder, error) {
+	var updated WorkOrder
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = recordAssignment(ctx, s.repo, s.publisher, wo, assignee, action, reason)
+		return err
+	})
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	if err := s.syncTasks(ctx, updated); err != nil {
+		log.Printf("workorder %s: %v", updated.ID, err)
+	}
+	return updated, nil
+}
+
+func (s *service) syncTasks(ctx context.Context, wo WorkOrder) error {
+	if s.runtime == nil {
+		return nil
+	}
+	return assignTasks(ctx, s.runtime, wo)
+}
+
+type assignmentRepository interface {
+	UpdateAssignee(ctx context.Context, id, from, to string) (WorkOrder, error)
+	RecordAssignment(ctx context.Context, a Assignment) error
+}
+
+type AssignmentPublisher interface {
+	PublishWorkOrderAssigned(ctx context.Context, wo WorkOrder, previous string) error
+}
+
+func recordAssignment(ctx context.Context, repo assignmentRepository, publisher AssignmentPublisher, wo WorkOrder, assignee string, action AssignmentAction, reason string) (WorkOrder, error) {
+	updated, err := repo.UpdateAssignee(ctx, wo.ID, wo.Assignee, assignee)
+	if err != nil {
+		switch {
+		case errors.Is(err, sqlErrAssigneeConflict):
+			return WorkOrder{}, &AssignmentError{ID: wo.ID, Current: "another user"}
+		case errors.Is(err, sqlErrNotFound):
+			return WorkOrder{}, notFoundError{id: wo.ID}
+		}
+		return WorkOrder{}, err
+	}
+
+	if err := repo.RecordAssignment(ctx, Assignment{
+		WorkOrderID:  wo.ID,
+		Action:       action,
+		FromAssignee: wo.Assignee,
+		ToAssignee:   assignee,
+		Reason:       reason,
+		Actor:        ActorFromContext(ctx),
+		CreatedAt:    updated.UpdatedAt,
+	}); err != nil {
+		return WorkOrder{}, err
+	}
+
+	if publisher != nil {
+		if err := publisher.PublishWorkOrderAssigned(ctx, updated, wo.Assignee); err != nil {
+			return WorkOrder{}, fmt.Errorf("publish workorder assigned: %w", err)
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,152 @@
+package workorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const MaxCommentLength = 10000
+
+type Comment struct {
+	ID          string    `json:"id" db:"id"`
+	WorkOrderID string    `json:"workOrderId" db:"workorder_id"`
+	Author      string    `json:"author" db:"author"`
+	Body        string    `json:"body" db:"body"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type CommentError struct {
+	Message string
+}
+
+func (e *CommentError) Error() string { return e.Message }
+
+func IsInvalidComment(err error) bool {
+	var target *CommentError
+	return errors.As(err, &target)
+}
+
+type CommentAuthorError struct {
+	ID     string
+	Author string
+	Actor  string
+}
+
+func (e *CommentAuthorError) Error() string {
+	return fmt.Sprintf("comment %s was written by %s and cannot be changed by %s", e.ID, e.Author, e.Actor)
+}
+
+func IsNotCommentAuthor(err error) bool {
+	var target *CommentAuthorError
+	return errors.As(err, &target)
+}
+
+type commentNotFoundError struct{ id string }
+
+func (e commentNotFoundError) Error() string { return fmt.Sprintf("comment %s not found", e.id) }
+
+func (commentNotFoundError) NotFound() {}
+
+func (s *service) Comments(ctx context.Context, id string) ([]Comment, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListComments(ctx, id)
+}
+
+func (s *service) AddComment(ctx context.Context, id, body string) (Comment, error) {
+	body, err := normalizeCommentBody(body)
+	if err != nil {
+		return Comment{}, err
+	}
+	if _, err := s.Get(ctx, id); err != nil {
+		return Comment{}, err
+	}
+
+	now := time.Now().UTC()
+	created, err := s.repo.CreateComment(ctx, Comment{
+		ID:          uuid.NewString(),
+		WorkOrderID: id,
+		Author:      ActorFromContext(ctx),
+		Body:        body,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Comment{}, notFoundError{id: id}
+		}
+		return Comment{}, err
+	}
+	return created, nil
+}
+
+func (s *service) EditComment(ctx context.Context, id, commentID, body string) (Comment, error) {
+	body, err := normalizeCommentBody(body)
+	if err != nil {
+		return Comment{}, err
+	}
+	comment, err := s.ownComment(ctx, id, commentID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if comment.Body == body {
+		return comment, nil
+	}
+
+	comment.Body = body
+	comment.UpdatedAt = time.Now().UTC()
+	updated, err := s.repo.UpdateComment(ctx, comment)
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Comment{}, commentNotFoundError{id: commentID}
+		}
+		return Comment{}, err
+	}
+	return updated, nil
+}
+
+func (s *service) DeleteComment(ctx context.Context, id, commentID string) error {
+	if _, err := s.ownComment(ctx, id, commentID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteComment(ctx, id, commentID, time.Now().UTC()); err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return commentNotFoundError{id: commentID}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) ownComment(ctx context.Context, id, commentID string) (Comment, error) {
+	comment, err := s.repo.GetComment(ctx, id, commentID)
+	if err != nil {
+		if errors.Is(err, sqlErrNotFound) {
+			return Comment{}, commentNotFoundError{id: commentID}
+		}
+		return Comment{}, err
+	}
+	if actor := ActorFromContext(ctx); comment.Author != actor {
+		return Comment{}, &CommentAuthorError{ID: comment.ID, Author: comment.Author, Actor: actor}
+	}
+	return comment, nil
+}
+
+func normalizeCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", &CommentError{Message: "comment body is required"}
+	}
+	if utf8.RuneCountInString(body) > MaxCommentLength {
+		return "", &CommentError{Message: fmt.Sprintf("comment body exceeds %d characters", MaxCommentLength)}
+	}
+	return body, nil
+}
@@ -16,5 +16,20 @@ func (a FlowServiceAdapter) Get(ctx context.Context, id string) (FlowSummary, er
 		return FlowSummary{}, err
 	}
 
-	return FlowSummary{ID: f.ID, Name: f.Name}, nil
+	return FlowSummary{
+		ID:        f.ID,
+		Name:      f.Name,
+		Version:   f.PublishedVersion,
+		Status:    string(f.Status),
+		Published: f.Status == flow.StatusPublished,
+		Metadata:  f.Metadata,
+	}, nil
+}
+
+func (a FlowServiceAdapter) ProcessDefinitionID(ctx context.Context, flowID string, version int) (string, error) {
+	snapshot, err := a.Service.GetVersion(ctx, flowID, version)
+	if err != nil {
+		return "", err
+	}
+	return snapshot.ProcessDefinitionID, nil
 }
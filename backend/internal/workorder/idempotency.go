@@ -0,0 +1,65 @@
+package workorder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const MaxIdempotencyKeyLength = 255
+
+type IdempotencyRecord struct {
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"`
+	WorkOrderID string    `db:"workorder_id"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type IdempotencyError struct {
+	Field string
+	Value string
+}
+
+func (e *IdempotencyError) Error() string {
+	return fmt.Sprintf("%s %q was already used with a different request body", e.Field, e.Value)
+}
+
+func IsIdempotencyMismatch(err error) bool {
+	var target *IdempotencyError
+	return errors.As(err, &target)
+}
+
+func fingerprint(input CreateInput) (string, error) {
+	if input.Payload == nil {
+		input.Payload = map[string]any{}
+	}
+	if input.Metadata == nil {
+		input.Metadata = map[string]string{}
+	}
+	var candidates *Candidates
+	if len(input.Candidates.Users) > 0 || len(input.Candidates.Groups) > 0 {
+		candidates = &input.Candidates
+	}
+	priority := input.Priority
+	if priority == PriorityNormal {
+		priority = ""
+	}
+	data, err := json.Marshal(struct {
+		FlowID     string            `json:"flowId"`
+		ExternalID string            `json:"externalId"`
+		Title      string            `json:"title"`
+		Priority   Priority          `json:"priority,omitempty"`
+		Assignee   string            `json:"assignee"`
+		Candidates *Candidates       `json:"candidates,omitempty"`
+		Payload    map[string]any    `json:"payload"`
+		Metadata   map[string]string `json:"metadata"`
+	}{input.FlowID, input.ExternalID, input.Title, priority, input.Assignee, candidates, input.Payload, input.Metadata})
+	if err != nil {
+		return "", fmt.Errorf("fingerprint request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
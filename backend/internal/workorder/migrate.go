@@ -0,0 +1,36 @@
+package workorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type MigrationPublisher interface {
+	PublishWorkOrderMigrated(ctx context.Context, wo WorkOrder, fromVersion int) error
+}
+
+func (s *service) RecordMigration(ctx context.Context, id string, fromVersion, toVersion int, processDefinitionID string) (WorkOrder, error) {
+	var migrated WorkOrder
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		migrated, err = s.repo.UpdateFlowVersion(ctx, id, fromVersion, toVersion, processDefinitionID)
+		if err != nil {
+			if errors.Is(err, sqlErrNotFound) {
+				return notFoundError{id: id}
+			}
+			return err
+		}
+
+		if s.publisher != nil {
+			if err := s.publisher.PublishWorkOrderMigrated(ctx, migrated, fromVersion); err != nil {
+				return fmt.Errorf("publish workorder migrated: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	return migrated, nil
+}
@@ -5,20 +5,74 @@ import "time"
 type Status string
 
 type WorkOrder struct {
-	ID        string            `json:"id" db:"id"`
-	FlowID    string            `json:"flowId" db:"flow_id"`
-	Title     string            `json:"title" db:"title"`
-	Assignee  string            `json:"assignee" db:"assignee"`
-	Status    Status            `json:"status" db:"status"`
-	Payload   map[string]any    `json:"payload" db:"payload"`
-	Metadata  map[string]string `json:"metadata" db:"metadata"`
-	CreatedAt time.Time         `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time         `json:"updatedAt" db:"updated_at"`
+	ID                  string            `json:"id" db:"id"`
+	TenantID            string            `json:"tenantId" db:"tenant_id"`
+	FlowID              string            `json:"flowId" db:"flow_id"`
+	FlowVersion         int               `json:"flowVersion" db:"flow_version"`
+	ExternalID          string            `json:"externalId,omitempty" db:"external_id"`
+	Title               string            `json:"title" db:"title"`
+	Assignee            string            `json:"assignee" db:"assignee"`
+	CandidateUsers      []string          `json:"candidateUsers" db:"candidate_users"`
+	CandidateGroups     []string          `json:"candidateGroups" db:"candidate_groups"`
+	Status              Status            `json:"status" db:"status"`
+	CurrentStep         string            `json:"currentStep" db:"current_step"`
+	Priority            Priority          `json:"priority" db:"priority"`
+	SLAState            SLAState          `json:"slaState" db:"sla_state"`
+	DueAt               *time.Time        `json:"dueAt,omitempty" db:"due_at"`
+	SLAWarningAt        *time.Time        `json:"slaWarningAt,omitempty" db:"sla_warning_at"`
+	StartStatus         StartStatus       `json:"startStatus" db:"start_status"`
+	StartAttempts       int               `json:"startAttempts" db:"start_attempts"`
+	StartError          string            `json:"startError,omitempty" db:"start_error"`
+	NextStartAt         *time.Time        `json:"nextStartAt,omitempty" db:"next_start_at"`
+	ProcessInstanceID   string            `json:"processInstanceId,omitempty" db:"process_instance_id"`
+	ProcessDefinitionID string            `json:"processDefinitionId,omitempty" db:"process_definition_id"`
+	BusinessKey         string            `json:"businessKey,omitempty" db:"business_key"`
+	Payload             map[string]any    `json:"payload" db:"payload"`
+	Metadata            map[string]string `json:"metadata" db:"metadata"`
+	CreatedAt           time.Time         `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time         `json:"updatedAt" db:"updated_at"`
+	RequestFingerprint  string            `json:"-" db:"request_fingerprint"`
+}
+
+type ProcessInstance struct {
+	ID           string `json:"id"`
+	DefinitionID string `json:"definitionId"`
+	BusinessKey  string `json:"businessKey"`
+}
+
+type ProcessState struct {
+	ProcessInstance
+	State            string     `json:"state"`
+	Suspended        bool       `json:"suspended"`
+	Ended            bool       `json:"ended"`
+	StartTime        *time.Time `json:"startTime,omitempty"`
+	EndTime          *time.Time `json:"endTime,omitempty"`
+	ActiveActivities []string   `json:"activeActivities"`
+	UserTasks        []UserTask `json:"userTasks"`
+	Incidents        []Incident `json:"incidents"`
+}
+
+type UserTask struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	ActivityID string    `json:"activityId"`
+	Assignee   string    `json:"assignee,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type Incident struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	ActivityID string    `json:"activityId"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"createdAt"`
 }
 
 const (
-	StatusPending  Status = "pending"
-	StatusRunning  Status = "running"
-	StatusFailed   Status = "failed"
-	StatusComplete Status = "complete"
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusFailed    Status = "failed"
+	StatusComplete  Status = "complete"
+	StatusCancelled Status = "cancelled"
+	StatusSuspended Status = "suspended"
 )
@@ -0,0 +1,226 @@
+package workorder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+type SortField string
+
+const (
+	SortCreatedAt SortField = "createdAt"
+	SortUpdatedAt SortField = "updatedAt"
+)
+
+var sortColumns = map[SortField]string{
+	SortCreatedAt: "created_at",
+	SortUpdatedAt: "updated_at",
+}
+
+type ListFilter struct {
+	Statuses      []Status
+	FlowID        string
+	FlowVersion   int
+	Assignee      string
+	Priorities    []Priority
+	SLAStates     []SLAState
+	StartStatuses []StartStatus
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Metadata      map[string]string
+	Sort          SortField
+	Ascending     bool
+	Limit         int
+	Cursor        string
+}
+
+type Page struct {
+	Items      []WorkOrder `json:"items"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+type QueryError struct {
+	Field   string
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+func IsInvalidQuery(err error) bool {
+	var target *QueryError
+	return errors.As(err, &target)
+}
+
+func (f ListFilter) normalize() (ListFilter, error) {
+	if f.Sort == "" {
+		f.Sort = SortCreatedAt
+	}
+	if _, ok := sortColumns[f.Sort]; !ok {
+		return ListFilter{}, &QueryError{Field: "sort", Message: fmt.Sprintf("unsupported sort field %q", f.Sort)}
+	}
+	if f.FlowVersion < 0 {
+		return ListFilter{}, &QueryError{Field: "flowVersion", Message: "must be a positive integer"}
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+	for _, status := range f.Statuses {
+		if _, ok := transitions[status]; !ok {
+			return ListFilter{}, &QueryError{Field: "status", Message: fmt.Sprintf("unknown status %q", status)}
+		}
+	}
+	for _, priority := range f.Priorities {
+		if !priority.Valid() {
+			return ListFilter{}, &QueryError{Field: "priority", Message: fmt.Sprintf("unknown priority %q", priority)}
+		}
+	}
+	for _, state := range f.SLAStates {
+		if !state.Valid() {
+			return ListFilter{}, &QueryError{Field: "slaState", Message: fmt.Sprintf("unknown sla state %q", state)}
+		}
+	}
+	for _, status := range f.StartStatuses {
+		if !status.Valid() {
+			return ListFilter{}, &QueryError{Field: "startStatus", Message: fmt.Sprintf("unknown start status %q", status)}
+		}
+	}
+	return f, nil
+}
+
+func (f ListFilter) cursorKey() string {
+	if f.Ascending {
+		return string(f.Sort) + ":asc"
+	}
+	return string(f.Sort) + ":desc"
+}
+
+type listQuery struct {
+	where []string
+	args  []any
+}
+
+func (q *listQuery) add(clause string, args ...any) {
+	for _, arg := range args {
+		q.args = append(q.args, arg)
+		clause = strings.Replace(clause, "?", fmt.Sprintf("$%d", len(q.args)), 1)
+	}
+	q.where = append(q.where, clause)
+}
+
+func addIn[T ~string](q *listQuery, column string, values []T) {
+	placeholders := make([]string, len(values))
+	args := make([]any, len(values))
+	for i, value := range values {
+		placeholders[i] = "?"
+		args[i] = value
+	}
+	q.add(column+" IN ("+strings.Join(placeholders, ", ")+")", args...)
+}
+
+func buildListQuery(tenantID string, f ListFilter) (string, []any, error) {
+	var q listQuery
+
+	q.add("tenant_id = ?", tenantID)
+
+	if len(f.Statuses) > 0 {
+		addIn(&q, "status", f.Statuses)
+	}
+	if len(f.Priorities) > 0 {
+		addIn(&q, "priority", f.Priorities)
+	}
+	if len(f.SLAStates) > 0 {
+		addIn(&q, "sla_state", f.SLAStates)
+	}
+	if len(f.StartStatuses) > 0 {
+		addIn(&q, "start_status", f.StartStatuses)
+	}
+	if f.FlowID != "" {
+		q.add("flow_id = ?", f.FlowID)
+	}
+	if f.FlowVersion > 0 {
+		q.add("flow_version = ?", f.FlowVersion)
+	}
+	if f.Assignee != "" {
+		q.add("assignee = ?", f.Assignee)
+	}
+	if f.DueAfter != nil {
+		q.add("due_at >= ?", *f.DueAfter)
+	}
+	if f.DueBefore != nil {
+		q.add("due_at < ?", *f.DueBefore)
+	}
+	if f.CreatedAfter != nil {
+		q.add("created_at >= ?", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		q.add("created_at < ?", *f.CreatedBefore)
+	}
+	if f.UpdatedAfter != nil {
+		q.add("updated_at >= ?", *f.UpdatedAfter)
+	}
+	if f.UpdatedBefore != nil {
+		q.add("updated_at < ?", *f.UpdatedBefore)
+	}
+	if len(f.Metadata) > 0 {
+		data, err := json.Marshal(f.Metadata)
+		if err != nil {
+			return "", nil, fmt.Errorf("marshal metadata filter: %w", err)
+		}
+		q.add("metadata @> ?::jsonb", string(data))
+	}
+
+	column := sortColumns[f.Sort]
+	direction, comparator := "DESC", "<"
+	if f.Ascending {
+		direction, comparator = "ASC", ">"
+	}
+
+	if f.Cursor != "" {
+		cursor, err := persistence.DecodeCursor(f.Cursor)
+		if err != nil || cursor.Sort != f.cursorKey() {
+			return "", nil, &QueryError{Field: "cursor", Message: "cursor does not match this query"}
+		}
+		value, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return "", nil, &QueryError{Field: "cursor", Message: "malformed cursor value"}
+		}
+		q.add(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparator), value, cursor.ID)
+	}
+
+	query := `SELECT ` + workOrderColumns + ` FROM workorders WHERE ` + strings.Join(q.where, " AND ")
+	q.args = append(q.args, f.Limit+1)
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT $%d", column, direction, direction, len(q.args))
+
+	return query, q.args, nil
+}
+
+func nextCursor(f ListFilter, last WorkOrder) string {
+	value := last.CreatedAt
+	if f.Sort == SortUpdatedAt {
+		value = last.UpdatedAt
+	}
+	return persistence.EncodeCursor(persistence.Cursor{
+		Sort:  f.cursorKey(),
+		Value: value.UTC().Format(time.RFC3339Nano),
+		ID:    last.ID,
+	})
+}
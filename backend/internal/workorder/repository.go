@@ -9,8 +9,13 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
 )
 
+const workOrderColumns = `id, tenant_id, flow_id, flow_version, COALESCE(external_id, ''), title, COALESCE(assignee, '') AS assignee, candidate_users, candidate_groups, status, current_step, priority, sla_state, due_at, sla_warning_at, start_status, start_attempts, start_error, next_start_at, COALESCE(process_instance_id, ''), COALESCE(process_definition_id, ''), COALESCE(business_key, ''), payload, metadata, created_at, updated_at, COALESCE(request_fingerprint, '')`
+
 type repository struct {
 	db *sqlx.DB
 }
@@ -19,31 +24,52 @@ func NewRepository(db *sqlx.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) List(ctx context.Context) ([]WorkOrder, error) {
-	const query = `SELECT id, flow_id, title, assignee, status, payload, metadata, created_at, updated_at FROM workorders ORDER BY created_at DESC`
+func (r *repository) List(ctx context.Context, filter ListFilter) (Page, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+
+	query, args, err := buildListQuery(tenantID, filter)
+	if err != nil {
+		return Page{}, err
+	}
 
-	rows, err := r.db.QueryxContext(ctx, query)
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list workorders: %w", err)
+		return Page{}, fmt.Errorf("list workorders: %w", err)
 	}
 	defer rows.Close()
 
-	var result []WorkOrder
+	page := Page{Items: []WorkOrder{}}
 	for rows.Next() {
 		wo, err := scanWorkOrder(rows)
 		if err != nil {
-			return nil, err
+			return Page{}, err
 		}
-		result = append(result, wo)
+		page.Items = append(page.Items, wo)
+	}
+	if err := rows.Err(); err != nil {
+		return Page{}, err
 	}
 
-	return result, rows.Err()
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		page.NextCursor = nextCursor(filter, page.Items[len(page.Items)-1])
+	}
+
+	return page, nil
 }
 
 func (r *repository) Get(ctx context.Context, id string) (WorkOrder, error) {
-	const query = `SELECT id, flow_id, title, assignee, status, payload, metadata, created_at, updated_at FROM workorders WHERE id = $1`
+	const query = `SELECT ` + workOrderColumns + ` FROM workorders WHERE id = $1 AND tenant_id = $2`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
 
-	row := r.db.QueryRowxContext(ctx, query, id)
+	row := persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, tenantID)
 
 	wo, err := scanWorkOrder(row)
 	if err != nil {
@@ -57,8 +83,17 @@ func (r *repository) Get(ctx context.Context, id string) (WorkOrder, error) {
 }
 
 func (r *repository) Create(ctx context.Context, wo WorkOrder) (WorkOrder, error) {
-	const query = `INSERT INTO workorders (id, flow_id, title, assignee, status, payload, metadata, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`
+	const query = `INSERT INTO workorders (id, tenant_id, flow_id, flow_version, external_id, title, assignee, candidate_users, candidate_groups, status, priority, sla_state, due_at, sla_warning_at, start_status, next_start_at, payload, metadata, created_at, updated_at, request_fingerprint) VALUES ($1,$2,$3,$4,NULLIF($5, ''),$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,NULLIF($21, ''))`
 
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	users, groups, err := marshalCandidates(Candidates{Users: wo.CandidateUsers, Groups: wo.CandidateGroups})
+	if err != nil {
+		return WorkOrder{}, err
+	}
 	payload, err := json.Marshal(wo.Payload)
 	if err != nil {
 		return WorkOrder{}, fmt.Errorf("marshal payload: %w", err)
@@ -69,21 +104,110 @@ func (r *repository) Create(ctx context.Context, wo WorkOrder) (WorkOrder, error
 	}
 
 	now := time.Now().UTC()
+	wo.TenantID = tenantID
 	wo.CreatedAt = now
 	wo.UpdatedAt = now
 
-	_, err = r.db.ExecContext(ctx, query, wo.ID, wo.FlowID, wo.Title, wo.Assignee, wo.Status, payload, metadata, wo.CreatedAt, wo.UpdatedAt)
+	_, err = persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, wo.ID, wo.TenantID, wo.FlowID, wo.FlowVersion, wo.ExternalID, wo.Title, wo.Assignee, users, groups, wo.Status, wo.Priority, wo.SLAState, wo.DueAt, wo.SLAWarningAt, wo.StartStatus, wo.NextStartAt, payload, metadata, wo.CreatedAt, wo.UpdatedAt, wo.RequestFingerprint)
 	if err != nil {
+		if persistence.IsUniqueViolation(err, "uq_workorders_external_id") {
+			return WorkOrder{}, sqlErrDuplicateExternalID
+		}
 		return WorkOrder{}, fmt.Errorf("insert workorder: %w", err)
 	}
 
 	return wo, nil
 }
 
-func (r *repository) UpdateStatus(ctx context.Context, id string, status Status) error {
-	const query = `UPDATE workorders SET status = $2, updated_at = $3 WHERE id = $1`
+func (r *repository) GetByExternalID(ctx context.Context, flowID, externalID string) (WorkOrder, error) {
+	const query = `SELECT ` + workOrderColumns + ` FROM workorders WHERE tenant_id = $1 AND flow_id = $2 AND external_id = $3`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	wo, err := scanWorkOrder(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, tenantID, flowID, externalID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, sqlErrNotFound
+		}
+		return WorkOrder{}, err
+	}
+	return wo, nil
+}
+
+func (r *repository) ListByProcessInstances(ctx context.Context, instanceIDs []string) ([]WorkOrder, error) {
+	const query = `SELECT ` + workOrderColumns + ` FROM workorders WHERE tenant_id = $1 AND process_instance_id = ANY($2)`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, tenantID, instanceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list workorders by process instance: %w", err)
+	}
+	defer rows.Close()
+
+	result := []WorkOrder{}
+	for rows.Next() {
+		wo, err := scanWorkOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, wo)
+	}
+	return result, rows.Err()
+}
+
+func (r *repository) GetIdempotencyKey(ctx context.Context, key string) (IdempotencyRecord, error) {
+	const query = `SELECT key, fingerprint, workorder_id, created_at FROM idempotency_keys WHERE tenant_id = $1 AND key = $2`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return IdempotencyRecord{}, err
+	}
+
+	var record IdempotencyRecord
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &record, query, tenantID, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return IdempotencyRecord{}, sqlErrNotFound
+		}
+		return IdempotencyRecord{}, fmt.Errorf("get idempotency key: %w", err)
+	}
+	return record, nil
+}
+
+func (r *repository) SaveIdempotencyKey(ctx context.Context, record IdempotencyRecord) error {
+	const query = `INSERT INTO idempotency_keys (tenant_id, key, fingerprint, workorder_id, created_at) VALUES ($1,$2,$3,$4,$5)`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
 
-	res, err := r.db.ExecContext(ctx, query, id, status, time.Now().UTC())
+	_, err = persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, tenantID, record.Key, record.Fingerprint, record.WorkOrderID, record.CreatedAt)
+	if err != nil {
+		if persistence.IsUniqueViolation(err, "idempotency_keys_pkey") {
+			return sqlErrDuplicateIdempotencyKey
+		}
+		return fmt.Errorf("insert idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Transition(ctx context.Context, t Transition) error {
+	const query = `UPDATE workorders SET status = $3, updated_at = $4, sla_state = CASE WHEN $3 = $6 AND sla_state IN ($7, $8) THEN $9 ELSE sla_state END
+WHERE id = $1 AND status = $2 AND tenant_id = $5`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, t.WorkOrderID, t.From, t.To, t.CreatedAt, tenantID, StatusComplete, SLAOnTrack, SLAAtRisk, SLAMet)
 	if err != nil {
 		return fmt.Errorf("update status: %w", err)
 	}
@@ -93,26 +217,533 @@ func (r *repository) UpdateStatus(ctx context.Context, id string, status Status)
 		return fmt.Errorf("rows affected: %w", err)
 	}
 
+	if affected == 0 {
+		var exists bool
+		if err := persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, `SELECT EXISTS (SELECT 1 FROM workorders WHERE id = $1 AND tenant_id = $2)`, t.WorkOrderID, tenantID).Scan(&exists); err != nil {
+			return fmt.Errorf("check workorder: %w", err)
+		}
+		if exists {
+			return sqlErrStatusConflict
+		}
+		return sqlErrNotFound
+	}
+
+	return r.RecordTransition(ctx, t)
+}
+
+func (r *repository) RecordTransition(ctx context.Context, t Transition) error {
+	const query = `INSERT INTO workorder_transitions (workorder_id, from_status, to_status, reason, actor, created_at)
+SELECT id, $2, $3, $4, $5, $6 FROM workorders WHERE id = $1 AND tenant_id = $7`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, t.WorkOrderID, t.From, t.To, t.Reason, t.Actor, t.CreatedAt, tenantID)
+	if err != nil {
+		return fmt.Errorf("insert transition: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sqlErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) ListTransitions(ctx context.Context, id string) ([]Transition, error) {
+	const query = `SELECT t.id, t.workorder_id, t.from_status, t.to_status, t.reason, t.actor, t.created_at
+FROM workorder_transitions t JOIN workorders w ON w.id = t.workorder_id
+WHERE t.workorder_id = $1 AND w.tenant_id = $2
+ORDER BY t.created_at, t.id`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Transition
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &result, query, id, tenantID); err != nil {
+		return nil, fmt.Errorf("list transitions: %w", err)
+	}
+	return result, nil
+}
+
+func (r *repository) AttachProcess(ctx context.Context, id string, instance ProcessInstance) error {
+	const query = `UPDATE workorders SET process_instance_id = $2, process_definition_id = $3, business_key = $4, updated_at = $5,
+	start_status = $7, start_attempts = start_attempts + 1, start_error = '', next_start_at = NULL
+WHERE id = $1 AND tenant_id = $6`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, id, instance.ID, instance.DefinitionID, instance.BusinessKey, time.Now().UTC(), tenantID, StartStarted)
+	if err != nil {
+		return fmt.Errorf("attach process instance: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		return sqlErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) UpdateAssignee(ctx context.Context, id, from, to string) (WorkOrder, error) {
+	const query = `UPDATE workorders SET assignee = $3, updated_at = $4 WHERE id = $1 AND COALESCE(assignee, '') = $2 AND tenant_id = $5 RETURNING ` + workOrderColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	exec := persistence.ExecutorFromContext(ctx, r.db)
+	wo, err := scanWorkOrder(exec.QueryRowxContext(ctx, query, id, from, to, time.Now().UTC(), tenantID))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, fmt.Errorf("update assignee: %w", err)
+		}
+		var exists bool
+		if err := exec.QueryRowxContext(ctx, `SELECT EXISTS (SELECT 1 FROM workorders WHERE id = $1 AND tenant_id = $2)`, id, tenantID).Scan(&exists); err != nil {
+			return WorkOrder{}, fmt.Errorf("check workorder: %w", err)
+		}
+		if exists {
+			return WorkOrder{}, sqlErrAssigneeConflict
+		}
+		return WorkOrder{}, sqlErrNotFound
+	}
+	return wo, nil
+}
+
+func (r *repository) UpdateCurrentStep(ctx context.Context, id, step string) (WorkOrder, error) {
+	const query = `UPDATE workorders SET current_step = $2, updated_at = $3 WHERE id = $1 AND tenant_id = $4 RETURNING ` + workOrderColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	wo, err := scanWorkOrder(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, step, time.Now().UTC(), tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, sqlErrNotFound
+		}
+		return WorkOrder{}, fmt.Errorf("update current step: %w", err)
+	}
+	return wo, nil
+}
+
+func (r *repository) UpdateFlowVersion(ctx context.Context, id string, fromVersion, toVersion int, processDefinitionID string) (WorkOrder, error) {
+	const query = `UPDATE workorders SET flow_version = $3, process_definition_id = $4, updated_at = $5
+WHERE id = $1 AND tenant_id = $6 AND flow_version IN ($2, $3)
+RETURNING ` + workOrderColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	wo, err := scanWorkOrder(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, fromVersion, toVersion, processDefinitionID, time.Now().UTC(), tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, sqlErrNotFound
+		}
+		return WorkOrder{}, fmt.Errorf("update flow version: %w", err)
+	}
+	return wo, nil
+}
+
+func (r *repository) UpdateCandidates(ctx context.Context, id string, candidates Candidates) (WorkOrder, error) {
+	const query = `UPDATE workorders SET candidate_users = $2, candidate_groups = $3, updated_at = $4 WHERE id = $1 AND tenant_id = $5 RETURNING ` + workOrderColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	users, groups, err := marshalCandidates(candidates)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	wo, err := scanWorkOrder(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, users, groups, time.Now().UTC(), tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, sqlErrNotFound
+		}
+		return WorkOrder{}, fmt.Errorf("update candidates: %w", err)
+	}
+	return wo, nil
+}
+
+func (r *repository) RecordAssignment(ctx context.Context, a Assignment) error {
+	const query = `INSERT INTO workorder_assignments (workorder_id, action, from_assignee, to_assignee, reason, candidates, actor, created_at)
+SELECT id, $2, $3, $4, $5, $6, $7, $8 FROM workorders WHERE id = $1 AND tenant_id = $9`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, a.WorkOrderID, a.Action, a.FromAssignee, a.ToAssignee, a.Reason, a.Candidates, a.Actor, a.CreatedAt, tenantID)
+	if err != nil {
+		return fmt.Errorf("insert assignment: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
 	if affected == 0 {
 		return sqlErrNotFound
 	}
+	return nil
+}
+
+func (r *repository) ListAssignments(ctx context.Context, id string) ([]Assignment, error) {
+	const query = `SELECT a.id, a.workorder_id, a.action, a.from_assignee, a.to_assignee, a.reason, a.candidates, a.actor, a.created_at
+FROM workorder_assignments a JOIN workorders w ON w.id = a.workorder_id
+WHERE a.workorder_id = $1 AND w.tenant_id = $2
+ORDER BY a.created_at, a.id`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Assignment{}
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &result, query, id, tenantID); err != nil {
+		return nil, fmt.Errorf("list assignments: %w", err)
+	}
+	return result, nil
+}
+
+const commentColumns = `c.id, c.workorder_id, c.author, c.body, c.created_at, c.updated_at`
+
+func (r *repository) CreateComment(ctx context.Context, c Comment) (Comment, error) {
+	const query = `INSERT INTO workorder_comments AS c (id, workorder_id, author, body, created_at, updated_at)
+SELECT $2, id, $3, $4, $5, $6 FROM workorders WHERE id = $1 AND tenant_id = $7
+RETURNING ` + commentColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	var created Comment
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &created, query, c.WorkOrderID, c.ID, c.Author, c.Body, c.CreatedAt, c.UpdatedAt, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Comment{}, sqlErrNotFound
+		}
+		return Comment{}, fmt.Errorf("insert comment: %w", err)
+	}
+	return created, nil
+}
+
+func (r *repository) GetComment(ctx context.Context, workOrderID, id string) (Comment, error) {
+	const query = `SELECT ` + commentColumns + `
+FROM workorder_comments c JOIN workorders w ON w.id = c.workorder_id
+WHERE c.id = $1 AND c.workorder_id = $2 AND c.deleted_at IS NULL AND w.tenant_id = $3`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	var c Comment
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &c, query, id, workOrderID, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Comment{}, sqlErrNotFound
+		}
+		return Comment{}, fmt.Errorf("get comment: %w", err)
+	}
+	return c, nil
+}
+
+func (r *repository) UpdateComment(ctx context.Context, c Comment) (Comment, error) {
+	const query = `UPDATE workorder_comments AS c SET body = $3, updated_at = $4
+FROM workorders w
+WHERE c.id = $1 AND c.workorder_id = $2 AND c.deleted_at IS NULL AND w.id = c.workorder_id AND w.tenant_id = $5
+RETURNING ` + commentColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	var updated Comment
+	if err := sqlx.GetContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &updated, query, c.ID, c.WorkOrderID, c.Body, c.UpdatedAt, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Comment{}, sqlErrNotFound
+		}
+		return Comment{}, fmt.Errorf("update comment: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *repository) DeleteComment(ctx context.Context, workOrderID, id string, at time.Time) error {
+	const query = `UPDATE workorder_comments AS c SET deleted_at = $3
+FROM workorders w
+WHERE c.id = $1 AND c.workorder_id = $2 AND c.deleted_at IS NULL AND w.id = c.workorder_id AND w.tenant_id = $4`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := persistence.ExecutorFromContext(ctx, r.db).ExecContext(ctx, query, id, workOrderID, at, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete comment: %w", err)
+	}
 
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sqlErrNotFound
+	}
 	return nil
 }
 
+func (r *repository) ListComments(ctx context.Context, workOrderID string) ([]Comment, error) {
+	const query = `SELECT ` + commentColumns + `
+FROM workorder_comments c JOIN workorders w ON w.id = c.workorder_id
+WHERE c.workorder_id = $1 AND c.deleted_at IS NULL AND w.tenant_id = $2
+ORDER BY c.created_at, c.id`
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Comment{}
+	if err := sqlx.SelectContext(ctx, persistence.ExecutorFromContext(ctx, r.db), &result, query, workOrderID, tenantID); err != nil {
+		return nil, fmt.Errorf("list comments: %w", err)
+	}
+	return result, nil
+}
+
+func (r *repository) ClaimTracked(ctx context.Context, before time.Time, limit int) ([]WorkOrder, error) {
+	query := `UPDATE workorders SET synced_at = $1
+WHERE id IN (
+    SELECT id FROM workorders
+    WHERE process_instance_id IS NOT NULL AND status IN ($2, $3, $4, $5) AND (synced_at IS NULL OR synced_at <= $6)
+    ORDER BY synced_at NULLS FIRST
+    LIMIT $7
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + workOrderColumns
+
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, time.Now().UTC(), StatusPending, StatusRunning, StatusFailed, StatusSuspended, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim tracked workorders: %w", err)
+	}
+	defer rows.Close()
+
+	var result []WorkOrder
+	for rows.Next() {
+		wo, err := scanWorkOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, wo)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *repository) LockSLADue(ctx context.Context, now time.Time, limit int) ([]WorkOrder, error) {
+	query := `SELECT ` + workOrderColumns + ` FROM workorders
+WHERE sla_state IN ($1, $2) AND sla_warning_at <= $3 AND (sla_state = $1 OR due_at <= $3) AND status NOT IN ($4, $5)
+ORDER BY sla_warning_at
+LIMIT $6
+FOR UPDATE SKIP LOCKED`
+
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, SLAOnTrack, SLAAtRisk, now, StatusComplete, StatusCancelled, limit)
+	if err != nil {
+		return nil, fmt.Errorf("lock sla due workorders: %w", err)
+	}
+	defer rows.Close()
+
+	var result []WorkOrder
+	for rows.Next() {
+		wo, err := scanWorkOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, wo)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *repository) UpdateSLAState(ctx context.Context, id string, from, to SLAState) (WorkOrder, error) {
+	const query = `UPDATE workorders SET sla_state = $3, updated_at = $4 WHERE id = $1 AND sla_state = $2 AND tenant_id = $5 RETURNING ` + workOrderColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	wo, err := scanWorkOrder(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, from, to, time.Now().UTC(), tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, sqlErrNotFound
+		}
+		return WorkOrder{}, fmt.Errorf("update sla state: %w", err)
+	}
+	return wo, nil
+}
+
+func (r *repository) LockStartDue(ctx context.Context, now time.Time, limit int) ([]WorkOrder, error) {
+	query := `SELECT ` + workOrderColumns + ` FROM workorders
+WHERE start_status = $1 AND next_start_at <= $2 AND status = $3 AND process_instance_id IS NULL
+ORDER BY next_start_at
+LIMIT $4
+FOR UPDATE SKIP LOCKED`
+
+	rows, err := persistence.ExecutorFromContext(ctx, r.db).QueryxContext(ctx, query, StartPending, now, StatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("lock pending starts: %w", err)
+	}
+	defer rows.Close()
+
+	var result []WorkOrder
+	for rows.Next() {
+		wo, err := scanWorkOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, wo)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *repository) MarkStartFailed(ctx context.Context, id, cause string, next time.Time, maxAttempts int) (WorkOrder, error) {
+	const query = `UPDATE workorders SET
+	start_status = CASE WHEN start_attempts + 1 >= $4 THEN $5 ELSE $6 END,
+	next_start_at = CASE WHEN start_attempts + 1 >= $4 THEN NULL ELSE $3 END,
+	start_attempts = start_attempts + 1,
+	start_error = $2,
+	updated_at = $7
+WHERE id = $1 AND tenant_id = $8 AND process_instance_id IS NULL
+RETURNING ` + workOrderColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	wo, err := scanWorkOrder(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, cause, next, maxAttempts, StartFailed, StartPending, time.Now().UTC(), tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, sqlErrNotFound
+		}
+		return WorkOrder{}, fmt.Errorf("record start failure: %w", err)
+	}
+	return wo, nil
+}
+
+func (r *repository) DeferStart(ctx context.Context, id, cause string, next time.Time) (WorkOrder, error) {
+	const query = `UPDATE workorders SET next_start_at = $3, start_error = $2, updated_at = $4
+WHERE id = $1 AND tenant_id = $5 AND process_instance_id IS NULL
+RETURNING ` + workOrderColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	wo, err := scanWorkOrder(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, cause, next, time.Now().UTC(), tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, sqlErrNotFound
+		}
+		return WorkOrder{}, fmt.Errorf("defer start: %w", err)
+	}
+	return wo, nil
+}
+
+func (r *repository) ResetStart(ctx context.Context, id string, at time.Time) (WorkOrder, error) {
+	const query = `UPDATE workorders SET start_status = $2, start_attempts = 0, start_error = '', next_start_at = $3, updated_at = $3
+WHERE id = $1 AND tenant_id = $4 AND process_instance_id IS NULL
+RETURNING ` + workOrderColumns
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+
+	wo, err := scanWorkOrder(persistence.ExecutorFromContext(ctx, r.db).QueryRowxContext(ctx, query, id, StartPending, at, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkOrder{}, sqlErrNotFound
+		}
+		return WorkOrder{}, fmt.Errorf("reset start: %w", err)
+	}
+	return wo, nil
+}
+
+func marshalCandidates(c Candidates) ([]byte, []byte, error) {
+	if c.Users == nil {
+		c.Users = []string{}
+	}
+	if c.Groups == nil {
+		c.Groups = []string{}
+	}
+	users, err := json.Marshal(c.Users)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal candidate users: %w", err)
+	}
+	groups, err := json.Marshal(c.Groups)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal candidate groups: %w", err)
+	}
+	return users, groups, nil
+}
+
 func scanWorkOrder(scanner interface {
 	Scan(dest ...any) error
 }) (WorkOrder, error) {
 	var (
 		wo          WorkOrder
+		usersRaw    []byte
+		groupsRaw   []byte
 		payloadRaw  []byte
 		metadataRaw []byte
 	)
 
-	if err := scanner.Scan(&wo.ID, &wo.FlowID, &wo.Title, &wo.Assignee, &wo.Status, &payloadRaw, &metadataRaw, &wo.CreatedAt, &wo.UpdatedAt); err != nil {
+	if err := scanner.Scan(&wo.ID, &wo.TenantID, &wo.FlowID, &wo.FlowVersion, &wo.ExternalID, &wo.Title, &wo.Assignee, &usersRaw, &groupsRaw, &wo.Status, &wo.CurrentStep, &wo.Priority, &wo.SLAState, &wo.DueAt, &wo.SLAWarningAt, &wo.StartStatus, &wo.StartAttempts, &wo.StartError, &wo.NextStartAt, &wo.ProcessInstanceID, &wo.ProcessDefinitionID, &wo.BusinessKey, &payloadRaw, &metadataRaw, &wo.CreatedAt, &wo.UpdatedAt, &wo.RequestFingerprint); err != nil {
 		return WorkOrder{}, err
 	}
 
+	wo.CandidateUsers = []string{}
+	if len(usersRaw) > 0 {
+		if err := json.Unmarshal(usersRaw, &wo.CandidateUsers); err != nil {
+			return WorkOrder{}, fmt.Errorf("unmarshal candidate users: %w", err)
+		}
+	}
+
+	wo.CandidateGroups = []string{}
+	if len(groupsRaw) > 0 {
+		if err := json.Unmarshal(groupsRaw, &wo.CandidateGroups); err != nil {
+			return WorkOrder{}, fmt.Errorf("unmarshal candidate groups: %w", err)
+		}
+	}
+
 	if len(payloadRaw) > 0 {
 		if err := json.Unmarshal(payloadRaw, &wo.Payload); err != nil {
 			return WorkOrder{}, fmt.Errorf("unmarshal payload: %w", err)
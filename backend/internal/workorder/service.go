@@ -4,56 +4,133 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
 )
 
 type Service interface {
-	List(ctx context.Context) ([]WorkOrder, error)
-	Create(ctx context.Context, input CreateInput) (WorkOrder, error)
+	List(ctx context.Context, filter ListFilter) (Page, error)
+	Create(ctx context.Context, input CreateInput) (WorkOrder, bool, error)
 	Get(ctx context.Context, id string) (WorkOrder, error)
+	ByProcessInstances(ctx context.Context, instanceIDs []string) (map[string]WorkOrder, error)
+	Refresh(ctx context.Context, id string) (WorkOrder, error)
 	Retry(ctx context.Context, id string) error
+	Cancel(ctx context.Context, id, reason string) (WorkOrder, error)
+	Suspend(ctx context.Context, id, reason string) (WorkOrder, error)
+	Resume(ctx context.Context, id, reason string) (WorkOrder, error)
+	Assign(ctx context.Context, id, assignee, reason string) (WorkOrder, error)
+	Claim(ctx context.Context, id string) (WorkOrder, error)
+	Release(ctx context.Context, id, reason string) (WorkOrder, error)
+	SetCandidates(ctx context.Context, id string, candidates Candidates) (WorkOrder, error)
+	Assignments(ctx context.Context, id string) ([]Assignment, error)
+	Process(ctx context.Context, id string) (ProcessState, error)
+	Transitions(ctx context.Context, id string) ([]Transition, error)
+	Comments(ctx context.Context, id string) ([]Comment, error)
+	AddComment(ctx context.Context, id, body string) (Comment, error)
+	EditComment(ctx context.Context, id, commentID, body string) (Comment, error)
+	DeleteComment(ctx context.Context, id, commentID string) error
+	Timeline(ctx context.Context, id string, descending bool) ([]TimelineEntry, error)
+	RecordMigration(ctx context.Context, id string, fromVersion, toVersion int, processDefinitionID string) (WorkOrder, error)
 }
 
 type Repository interface {
-	List(ctx context.Context) ([]WorkOrder, error)
+	List(ctx context.Context, filter ListFilter) (Page, error)
 	Get(ctx context.Context, id string) (WorkOrder, error)
 	Create(ctx context.Context, wo WorkOrder) (WorkOrder, error)
-	UpdateStatus(ctx context.Context, id string, status Status) error
+	GetByExternalID(ctx context.Context, flowID, externalID string) (WorkOrder, error)
+	ListByProcessInstances(ctx context.Context, instanceIDs []string) ([]WorkOrder, error)
+	GetIdempotencyKey(ctx context.Context, key string) (IdempotencyRecord, error)
+	SaveIdempotencyKey(ctx context.Context, record IdempotencyRecord) error
+	Transition(ctx context.Context, t Transition) error
+	RecordTransition(ctx context.Context, t Transition) error
+	ListTransitions(ctx context.Context, id string) ([]Transition, error)
+	UpdateAssignee(ctx context.Context, id, from, to string) (WorkOrder, error)
+	UpdateCandidates(ctx context.Context, id string, candidates Candidates) (WorkOrder, error)
+	UpdateCurrentStep(ctx context.Context, id, step string) (WorkOrder, error)
+	UpdateFlowVersion(ctx context.Context, id string, fromVersion, toVersion int, processDefinitionID string) (WorkOrder, error)
+	RecordAssignment(ctx context.Context, a Assignment) error
+	ListAssignments(ctx context.Context, id string) ([]Assignment, error)
+	CreateComment(ctx context.Context, c Comment) (Comment, error)
+	GetComment(ctx context.Context, workOrderID, id string) (Comment, error)
+	UpdateComment(ctx context.Context, c Comment) (Comment, error)
+	DeleteComment(ctx context.Context, workOrderID, id string, at time.Time) error
+	ListComments(ctx context.Context, workOrderID string) ([]Comment, error)
+	ClaimTracked(ctx context.Context, before time.Time, limit int) ([]WorkOrder, error)
+	SLARepository
+	StartRepository
 }
 
 type FlowReader interface {
 	Get(ctx context.Context, id string) (FlowSummary, error)
+	ProcessDefinitionID(ctx context.Context, flowID string, version int) (string, error)
 }
 
 type FlowSummary struct {
-	ID   string
-	Name string
+	ID        string
+	Name      string
+	Version   int
+	Status    string
+	Published bool
+	Metadata  map[string]string
+}
+
+type FlowUnavailableError struct {
+	FlowID string
+	Status string
+}
+
+func (e *FlowUnavailableError) Error() string {
+	return fmt.Sprintf("flow %s is %s and does not accept new workorders", e.FlowID, e.Status)
+}
+
+func IsFlowUnavailable(err error) bool {
+	var target *FlowUnavailableError
+	return errors.As(err, &target)
 }
 
 type CamundaRuntime interface {
-	StartProcess(ctx context.Context, flowID string, payload map[string]any) error
-	RetryProcess(ctx context.Context, workOrderID string) error
+	StartProcess(ctx context.Context, processDefinitionID, businessKey string, payload map[string]any) (ProcessInstance, error)
+	RetryProcess(ctx context.Context, processInstanceID string) error
+	CancelProcess(ctx context.Context, processInstanceID, reason string) error
+	SuspendProcess(ctx context.Context, processInstanceID string) error
+	ResumeProcess(ctx context.Context, processInstanceID string) error
+	InspectProcess(ctx context.Context, processInstanceID string) (ProcessState, error)
+	ActivityHistory(ctx context.Context, processInstanceID string) ([]Activity, error)
+	AssignTasks(ctx context.Context, processInstanceID string, assignment TaskAssignment) error
 }
 
 type Publisher interface {
 	PublishWorkOrderCreated(ctx context.Context, wo WorkOrder) error
-	PublishWorkOrderCompleted(ctx context.Context, wo WorkOrder) error
+	AssignmentPublisher
+	StatusPublisher
+	MigrationPublisher
 }
 
 type CreateInput struct {
-	FlowID   string
-	Title    string
-	Assignee string
-	Payload  map[string]any
-	Metadata map[string]string
+	FlowID         string
+	ExternalID     string
+	IdempotencyKey string
+	Title          string
+	Priority       Priority
+	Assignee       string
+	Candidates     Candidates
+	Payload        map[string]any
+	Metadata       map[string]string
 }
 
 type service struct {
 	repo      Repository
+	tx        persistence.Transactor
 	flows     FlowReader
 	runtime   CamundaRuntime
 	publisher Publisher
+	sla       SLAResolver
+	starter   starter
 }
 
 type notFoundError struct{ id string }
@@ -63,59 +140,203 @@ func (e notFoundError) Error() string { return fmt.Sprintf("workorder %s not fou
 func (notFoundError) NotFound() {}
 
 func IsNotFound(err error) bool {
-	var target notFoundError
+	var target interface{ NotFound() }
 	return errors.As(err, &target)
 }
 
-func NewService(repo Repository, flows FlowReader, runtime CamundaRuntime, publisher Publisher) Service {
-	return &service{repo: repo, flows: flows, runtime: runtime, publisher: publisher}
+type NoProcessError struct {
+	ID string
 }
 
-func (s *service) List(ctx context.Context) ([]WorkOrder, error) {
-	return s.repo.List(ctx)
+func (e *NoProcessError) Error() string {
+	return fmt.Sprintf("workorder %s has no process instance", e.ID)
+}
+
+func IsNoProcess(err error) bool {
+	var target *NoProcessError
+	return errors.As(err, &target)
+}
+
+func NewService(repo Repository, tx persistence.Transactor, flows FlowReader, runtime CamundaRuntime, publisher Publisher, sla SLAResolver, retry config.RetryConfig) Service {
+	return &service{repo: repo, tx: tx, flows: flows, runtime: runtime, publisher: publisher, sla: sla, starter: starter{repo: repo, flows: flows, runtime: runtime, retry: retry}}
+}
+
+func (s *service) List(ctx context.Context, filter ListFilter) (Page, error) {
+	filter, err := filter.normalize()
+	if err != nil {
+		return Page{}, err
+	}
+	return s.repo.List(ctx, filter)
 }
 
-func (s *service) Create(ctx context.Context, input CreateInput) (WorkOrder, error) {
+func (s *service) Create(ctx context.Context, input CreateInput) (WorkOrder, bool, error) {
 	if input.FlowID == "" {
-		return WorkOrder{}, errors.New("flow id is required")
+		return WorkOrder{}, false, errors.New("flow id is required")
 	}
 	if input.Title == "" {
-		return WorkOrder{}, errors.New("title is required")
+		return WorkOrder{}, false, errors.New("title is required")
+	}
+	if len(input.IdempotencyKey) > MaxIdempotencyKeyLength {
+		return WorkOrder{}, false, fmt.Errorf("idempotency key exceeds %d characters", MaxIdempotencyKeyLength)
+	}
+	if input.Priority == "" {
+		input.Priority = PriorityNormal
+	}
+	if !input.Priority.Valid() {
+		return WorkOrder{}, false, &InputError{Field: "priority", Message: fmt.Sprintf("unknown priority %q", input.Priority)}
+	}
+
+	fp, err := fingerprint(input)
+	if err != nil {
+		return WorkOrder{}, false, err
+	}
+
+	if existing, ok, err := s.replay(ctx, input, fp); err != nil || ok {
+		return existing, ok, err
 	}
 
 	flow, err := s.flows.Get(ctx, input.FlowID)
 	if err != nil {
-		return WorkOrder{}, fmt.Errorf("load flow: %w", err)
+		return WorkOrder{}, false, fmt.Errorf("load flow: %w", err)
+	}
+	if !flow.Published {
+		return WorkOrder{}, false, &FlowUnavailableError{FlowID: flow.ID, Status: flow.Status}
 	}
 
 	wo := WorkOrder{
-		ID:       uuid.NewString(),
-		FlowID:   flow.ID,
-		Title:    input.Title,
-		Assignee: input.Assignee,
-		Status:   StatusPending,
-		Payload:  input.Payload,
-		Metadata: input.Metadata,
+		ID:                 uuid.NewString(),
+		FlowID:             flow.ID,
+		FlowVersion:        flow.Version,
+		ExternalID:         input.ExternalID,
+		Title:              input.Title,
+		Priority:           input.Priority,
+		Assignee:           input.Assignee,
+		CandidateUsers:     input.Candidates.Users,
+		CandidateGroups:    input.Candidates.Groups,
+		Status:             StatusPending,
+		Payload:            input.Payload,
+		Metadata:           input.Metadata,
+		RequestFingerprint: fp,
+		StartStatus:        StartNone,
+	}
+	if s.runtime != nil {
+		now := time.Now().UTC()
+		wo.StartStatus = StartPending
+		wo.NextStartAt = &now
+	}
+	if wo, err = s.applySLA(ctx, wo, flow, time.Now().UTC()); err != nil {
+		return WorkOrder{}, false, err
 	}
 
-	saved, err := s.repo.Create(ctx, wo)
+	var saved WorkOrder
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		saved, err = s.repo.Create(ctx, wo)
+		if err != nil {
+			return err
+		}
+
+		if input.IdempotencyKey != "" {
+			if err := s.repo.SaveIdempotencyKey(ctx, IdempotencyRecord{
+				Key:         input.IdempotencyKey,
+				Fingerprint: fp,
+				WorkOrderID: saved.ID,
+				CreatedAt:   saved.CreatedAt,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.RecordTransition(ctx, Transition{
+			WorkOrderID: saved.ID,
+			To:          StatusPending,
+			Reason:      "created",
+			Actor:       ActorFromContext(ctx),
+			CreatedAt:   saved.CreatedAt,
+		}); err != nil {
+			return err
+		}
+
+		if saved.Assignee != "" {
+			if err := s.repo.RecordAssignment(ctx, Assignment{
+				WorkOrderID: saved.ID,
+				Action:      AssignmentAssign,
+				ToAssignee:  saved.Assignee,
+				Reason:      "created",
+				Actor:       ActorFromContext(ctx),
+				CreatedAt:   saved.CreatedAt,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if s.publisher != nil {
+			if err := s.publisher.PublishWorkOrderCreated(ctx, saved); err != nil {
+				return fmt.Errorf("publish workorder: %w", err)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, sqlErrDuplicateExternalID) || errors.Is(err, sqlErrDuplicateIdempotencyKey) {
+		existing, ok, replayErr := s.replay(ctx, input, fp)
+		if replayErr != nil {
+			return WorkOrder{}, false, replayErr
+		}
+		if ok {
+			return existing, true, nil
+		}
+	}
 	if err != nil {
-		return WorkOrder{}, err
+		return WorkOrder{}, false, err
 	}
 
 	if s.runtime != nil {
-		if err := s.runtime.StartProcess(ctx, saved.FlowID, saved.Payload); err != nil {
-			return WorkOrder{}, fmt.Errorf("start process: %w", err)
+		if saved, err = s.starter.start(ctx, saved); err != nil {
+			log.Printf("workorder %s: %v", saved.ID, err)
+			return saved, false, nil
+		}
+		if saved.Assignee != "" || len(saved.CandidateUsers) > 0 || len(saved.CandidateGroups) > 0 {
+			if err := s.syncTasks(ctx, saved); err != nil {
+				log.Printf("workorder %s: %v", saved.ID, err)
+			}
+		}
+	}
+
+	return saved, false, nil
+}
+
+func (s *service) replay(ctx context.Context, input CreateInput, fp string) (WorkOrder, bool, error) {
+	if input.IdempotencyKey != "" {
+		record, err := s.repo.GetIdempotencyKey(ctx, input.IdempotencyKey)
+		switch {
+		case err == nil:
+			if record.Fingerprint != fp {
+				return WorkOrder{}, false, &IdempotencyError{Field: "idempotency key", Value: input.IdempotencyKey}
+			}
+			wo, err := s.Get(ctx, record.WorkOrderID)
+			if err != nil {
+				return WorkOrder{}, false, err
+			}
+			return wo, true, nil
+		case !errors.Is(err, sqlErrNotFound):
+			return WorkOrder{}, false, err
 		}
 	}
 
-	if s.publisher != nil {
-		if err := s.publisher.PublishWorkOrderCreated(ctx, saved); err != nil {
-			return WorkOrder{}, fmt.Errorf("publish workorder: %w", err)
+	if input.ExternalID != "" {
+		wo, err := s.repo.GetByExternalID(ctx, input.FlowID, input.ExternalID)
+		switch {
+		case err == nil:
+			if wo.RequestFingerprint != fp {
+				return WorkOrder{}, false, &IdempotencyError{Field: "externalId", Value: input.ExternalID}
+			}
+			return wo, true, nil
+		case !errors.Is(err, sqlErrNotFound):
+			return WorkOrder{}, false, err
 		}
 	}
 
-	return saved, nil
+	return WorkOrder{}, false, nil
 }
 
 func (s *service) Get(ctx context.Context, id string) (WorkOrder, error) {
@@ -129,22 +350,208 @@ func (s *service) Get(ctx context.Context, id string) (WorkOrder, error) {
 	return wo, nil
 }
 
-func (s *service) Retry(ctx context.Context, id string) error {
-	wo, err := s.repo.Get(ctx, id)
+func (s *service) ByProcessInstances(ctx context.Context, instanceIDs []string) (map[string]WorkOrder, error) {
+	result := make(map[string]WorkOrder, len(instanceIDs))
+	if len(instanceIDs) == 0 {
+		return result, nil
+	}
+
+	orders, err := s.repo.ListByProcessInstances(ctx, instanceIDs)
 	if err != nil {
-		if errors.Is(err, sqlErrNotFound) {
-			return notFoundError{id: id}
+		return nil, err
+	}
+	for _, wo := range orders {
+		result[wo.ProcessInstanceID] = wo
+	}
+	return result, nil
+}
+
+func (s *service) Refresh(ctx context.Context, id string) (WorkOrder, error) {
+	wo, err := s.Get(ctx, id)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	if s.runtime == nil || wo.ProcessInstanceID == "" || wo.Status.Terminal() {
+		return wo, nil
+	}
+
+	state, err := s.runtime.InspectProcess(ctx, wo.ProcessInstanceID)
+	if err != nil {
+		return WorkOrder{}, fmt.Errorf("inspect process: %w", err)
+	}
+
+	var refreshed WorkOrder
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		refreshed, err = applyProcessState(ctx, s.repo, s.publisher, wo, state)
+		if IsInvalidTransition(err) {
+			return nil
 		}
 		return err
+	})
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	return refreshed, nil
+}
+
+func (s *service) Retry(ctx context.Context, id string) error {
+	wo, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if wo.Status == StatusSuspended || !CanTransition(wo.Status, StatusRunning) {
+		return &TransitionError{ID: wo.ID, From: wo.Status, To: StatusRunning}
 	}
 
 	if s.runtime != nil {
-		if err := s.runtime.RetryProcess(ctx, wo.ID); err != nil {
+		if wo.ProcessInstanceID == "" {
+			if wo, err = s.repo.ResetStart(ctx, wo.ID, time.Now().UTC()); err != nil {
+				if errors.Is(err, sqlErrNotFound) {
+					return notFoundError{id: id}
+				}
+				return err
+			}
+			if wo, err = s.starter.start(ctx, wo); err != nil {
+				return err
+			}
+		} else if err := s.runtime.RetryProcess(ctx, wo.ProcessInstanceID); err != nil {
 			return fmt.Errorf("retry process: %w", err)
 		}
 	}
 
-	return s.repo.UpdateStatus(ctx, id, StatusRunning)
+	return s.withinTransaction(ctx, func(ctx context.Context) error {
+		_, err := applyTransition(ctx, s.repo, s.publisher, wo, StatusRunning, "retry requested")
+		return err
+	})
+}
+
+func (s *service) Cancel(ctx context.Context, id, reason string) (WorkOrder, error) {
+	return s.changeProcessState(ctx, id, StatusCancelled, reason, func(ctx context.Context, wo WorkOrder, reason string) error {
+		if wo.ProcessInstanceID == "" {
+			return nil
+		}
+		if err := s.runtime.CancelProcess(ctx, wo.ProcessInstanceID, reason); err != nil {
+			return fmt.Errorf("cancel process: %w", err)
+		}
+		return nil
+	})
 }
 
-var sqlErrNotFound = errors.New("workorder not found")
+func (s *service) Suspend(ctx context.Context, id, reason string) (WorkOrder, error) {
+	return s.changeProcessState(ctx, id, StatusSuspended, reason, func(ctx context.Context, wo WorkOrder, _ string) error {
+		if wo.ProcessInstanceID == "" {
+			return &NoProcessError{ID: wo.ID}
+		}
+		if err := s.runtime.SuspendProcess(ctx, wo.ProcessInstanceID); err != nil {
+			return fmt.Errorf("suspend process: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *service) Resume(ctx context.Context, id, reason string) (WorkOrder, error) {
+	wo, err := s.Get(ctx, id)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	if wo.Status != StatusSuspended {
+		if wo.Status == StatusRunning {
+			return wo, nil
+		}
+		return WorkOrder{}, &TransitionError{ID: wo.ID, From: wo.Status, To: StatusRunning}
+	}
+
+	return s.changeProcessState(ctx, id, StatusRunning, reason, func(ctx context.Context, wo WorkOrder, _ string) error {
+		if wo.ProcessInstanceID == "" {
+			return &NoProcessError{ID: wo.ID}
+		}
+		if err := s.runtime.ResumeProcess(ctx, wo.ProcessInstanceID); err != nil {
+			return fmt.Errorf("resume process: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *service) changeProcessState(ctx context.Context, id string, to Status, reason string, apply func(ctx context.Context, wo WorkOrder, reason string) error) (WorkOrder, error) {
+	wo, err := s.Get(ctx, id)
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	if wo.Status == to {
+		return wo, nil
+	}
+	if !CanTransition(wo.Status, to) {
+		return WorkOrder{}, &TransitionError{ID: wo.ID, From: wo.Status, To: to}
+	}
+	if reason == "" {
+		reason = defaultReasons[to]
+	}
+
+	if s.runtime != nil {
+		if err := apply(ctx, wo, reason); err != nil {
+			return WorkOrder{}, err
+		}
+	}
+
+	var updated WorkOrder
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = applyTransition(ctx, s.repo, s.publisher, wo, to, reason)
+		return err
+	})
+	if IsInvalidTransition(err) {
+		if current, getErr := s.Get(ctx, id); getErr == nil && current.Status == to {
+			return current, nil
+		}
+	}
+	if err != nil {
+		return WorkOrder{}, err
+	}
+	return updated, nil
+}
+
+var defaultReasons = map[Status]string{
+	StatusCancelled: "cancelled",
+	StatusSuspended: "suspended",
+	StatusRunning:   "resumed",
+}
+
+func (s *service) Transitions(ctx context.Context, id string) ([]Transition, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTransitions(ctx, id)
+}
+
+func (s *service) Process(ctx context.Context, id string) (ProcessState, error) {
+	wo, err := s.Get(ctx, id)
+	if err != nil {
+		return ProcessState{}, err
+	}
+	if wo.ProcessInstanceID == "" {
+		return ProcessState{}, &NoProcessError{ID: id}
+	}
+	if s.runtime == nil {
+		return ProcessState{ProcessInstance: ProcessInstance{ID: wo.ProcessInstanceID, DefinitionID: wo.ProcessDefinitionID, BusinessKey: wo.BusinessKey}}, nil
+	}
+	return s.runtime.InspectProcess(ctx, wo.ProcessInstanceID)
+}
+
+func (s *service) withinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.tx == nil {
+		return fn(ctx)
+	}
+	return s.tx.WithinTransaction(ctx, fn)
+}
+
+var (
+	sqlErrNotFound       = errors.New("workorder not found")
+	sqlErrStatusConflict = errors.New("workorder status changed concurrently")
+
+	sqlErrAssigneeConflict = errors.New("workorder assignee changed concurrently")
+
+	sqlErrDuplicateExternalID     = errors.New("workorder external id already exists")
+	sqlErrDuplicateIdempotencyKey = errors.New("idempotency key already exists")
+)
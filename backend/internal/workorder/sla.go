@@ -0,0 +1,179 @@
+package workorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+func (p Priority) Valid() bool {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+type SLAState string
+
+const (
+	SLANone     SLAState = "none"
+	SLAOnTrack  SLAState = "on_track"
+	SLAAtRisk   SLAState = "at_risk"
+	SLABreached SLAState = "breached"
+	SLAMet      SLAState = "met"
+)
+
+func (s SLAState) Valid() bool {
+	switch s {
+	case SLANone, SLAOnTrack, SLAAtRisk, SLABreached, SLAMet:
+		return true
+	}
+	return false
+}
+
+func (s SLAState) Open() bool {
+	return s == SLAOnTrack || s == SLAAtRisk
+}
+
+type SLATarget struct {
+	Resolution time.Duration
+	WarnBefore time.Duration
+}
+
+type SLAResolver interface {
+	Resolve(ctx context.Context, flow FlowSummary, priority Priority) (SLATarget, bool, error)
+}
+
+type InputError struct {
+	Field   string
+	Message string
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+func IsInvalidInput(err error) bool {
+	var target *InputError
+	return errors.As(err, &target)
+}
+
+func (s *service) applySLA(ctx context.Context, wo WorkOrder, flow FlowSummary, now time.Time) (WorkOrder, error) {
+	wo.SLAState = SLANone
+	if s.sla == nil {
+		return wo, nil
+	}
+
+	target, ok, err := s.sla.Resolve(ctx, flow, wo.Priority)
+	if err != nil {
+		return WorkOrder{}, fmt.Errorf("resolve sla: %w", err)
+	}
+	if !ok || target.Resolution <= 0 {
+		return wo, nil
+	}
+
+	due := now.Add(target.Resolution)
+	warn := due.Add(-target.WarnBefore)
+	wo.DueAt = &due
+	wo.SLAWarningAt = &warn
+	wo.SLAState = SLAOnTrack
+	return wo, nil
+}
+
+type SLARepository interface {
+	LockSLADue(ctx context.Context, now time.Time, limit int) ([]WorkOrder, error)
+	UpdateSLAState(ctx context.Context, id string, from, to SLAState) (WorkOrder, error)
+}
+
+type SLAPublisher interface {
+	PublishWorkOrderSLAWarning(ctx context.Context, wo WorkOrder) error
+	PublishWorkOrderSLABreached(ctx context.Context, wo WorkOrder) error
+}
+
+type SLAMonitor struct {
+	repo      SLARepository
+	tx        persistence.Transactor
+	publisher SLAPublisher
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+func NewSLAMonitor(repo SLARepository, tx persistence.Transactor, publisher SLAPublisher, interval time.Duration, batchSize int) *SLAMonitor {
+	return &SLAMonitor{repo: repo, tx: tx, publisher: publisher, interval: interval, batchSize: batchSize, now: time.Now}
+}
+
+func (m *SLAMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := m.CheckOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("workorder sla: %v", err)
+		}
+		if n == m.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *SLAMonitor) CheckOnce(ctx context.Context) (int, error) {
+	var processed int
+	err := m.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := m.now().UTC()
+		orders, err := m.repo.LockSLADue(ctx, now, m.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, wo := range orders {
+			next := SLAAtRisk
+			if wo.DueAt != nil && !now.Before(*wo.DueAt) {
+				next = SLABreached
+			}
+			if err := m.escalate(tenant.WithTenant(ctx, wo.TenantID), wo, next); err != nil {
+				return fmt.Errorf("escalate %s: %w", wo.ID, err)
+			}
+		}
+
+		processed = len(orders)
+		return nil
+	})
+	return processed, err
+}
+
+func (m *SLAMonitor) escalate(ctx context.Context, wo WorkOrder, next SLAState) error {
+	updated, err := m.repo.UpdateSLAState(ctx, wo.ID, wo.SLAState, next)
+	if err != nil {
+		return err
+	}
+	if m.publisher == nil {
+		return nil
+	}
+	if next == SLABreached {
+		return m.publisher.PublishWorkOrderSLABreached(ctx, updated)
+	}
+	return m.publisher.PublishWorkOrderSLAWarning(ctx, updated)
+}
@@ -0,0 +1,213 @@
+package workorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/config"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+type StartStatus string
+
+const (
+	StartNone    StartStatus = "none"
+	StartPending StartStatus = "pending"
+	StartStarted StartStatus = "started"
+	StartFailed  StartStatus = "failed"
+)
+
+func (s StartStatus) Valid() bool {
+	switch s {
+	case StartNone, StartPending, StartStarted, StartFailed:
+		return true
+	}
+	return false
+}
+
+type StartRepository interface {
+	LockStartDue(ctx context.Context, now time.Time, limit int) ([]WorkOrder, error)
+	AttachProcess(ctx context.Context, id string, instance ProcessInstance) error
+	MarkStartFailed(ctx context.Context, id, cause string, next time.Time, maxAttempts int) (WorkOrder, error)
+	DeferStart(ctx context.Context, id, cause string, next time.Time) (WorkOrder, error)
+	ResetStart(ctx context.Context, id string, at time.Time) (WorkOrder, error)
+}
+
+type ProcessStarter interface {
+	StartProcess(ctx context.Context, processDefinitionID, businessKey string, payload map[string]any) (ProcessInstance, error)
+	CancelProcess(ctx context.Context, processInstanceID, reason string) error
+	AssignTasks(ctx context.Context, processInstanceID string, assignment TaskAssignment) error
+}
+
+type StartError struct {
+	WorkOrder WorkOrder
+	Err       error
+}
+
+func (e *StartError) Error() string {
+	return fmt.Sprintf("start process for workorder %s: %v", e.WorkOrder.ID, e.Err)
+}
+
+func (e *StartError) Unwrap() error { return e.Err }
+
+func IsStartFailed(err error) bool {
+	var target *StartError
+	return errors.As(err, &target)
+}
+
+type VersionNotDeployedError struct {
+	FlowID  string
+	Version int
+}
+
+func (e *VersionNotDeployedError) Error() string {
+	return fmt.Sprintf("flow %s version %d is not deployed yet", e.FlowID, e.Version)
+}
+
+func IsVersionNotDeployed(err error) bool {
+	var target *VersionNotDeployedError
+	return errors.As(err, &target)
+}
+
+type starter struct {
+	repo    StartRepository
+	flows   FlowReader
+	runtime ProcessStarter
+	retry   config.RetryConfig
+}
+
+func (s starter) start(ctx context.Context, wo WorkOrder) (WorkOrder, error) {
+	definitionID, err := s.flows.ProcessDefinitionID(ctx, wo.FlowID, wo.FlowVersion)
+	if err != nil {
+		return wo, fmt.Errorf("load process definition: %w", err)
+	}
+	if definitionID == "" {
+		notDeployed := &VersionNotDeployedError{FlowID: wo.FlowID, Version: wo.FlowVersion}
+		updated, err := s.repo.DeferStart(ctx, wo.ID, notDeployed.Error(), time.Now().UTC().Add(s.retry.Interval))
+		if err != nil {
+			return wo, fmt.Errorf("defer start: %w", err)
+		}
+		return updated, &StartError{WorkOrder: updated, Err: notDeployed}
+	}
+
+	instance, err := s.runtime.StartProcess(ctx, definitionID, wo.ID, wo.Payload)
+	if err != nil {
+		next := time.Now().UTC().Add(s.retry.Delay(wo.StartAttempts))
+		updated, markErr := s.repo.MarkStartFailed(ctx, wo.ID, err.Error(), next, s.retry.MaxAttempts)
+		if markErr != nil {
+			return wo, fmt.Errorf("record start failure: %w (start err: %v)", markErr, err)
+		}
+		return updated, &StartError{WorkOrder: updated, Err: err}
+	}
+
+	if err := s.repo.AttachProcess(ctx, wo.ID, instance); err != nil {
+		if cancelErr := s.runtime.CancelProcess(ctx, instance.ID, "pflow could not record the process instance"); cancelErr != nil {
+			log.Printf("workorder %s: cancel orphaned process %s: %v", wo.ID, instance.ID, cancelErr)
+		}
+		return wo, fmt.Errorf("attach process instance: %w", err)
+	}
+
+	wo.ProcessInstanceID = instance.ID
+	wo.ProcessDefinitionID = instance.DefinitionID
+	wo.BusinessKey = instance.BusinessKey
+	wo.StartStatus = StartStarted
+	wo.StartAttempts++
+	wo.StartError = ""
+	wo.NextStartAt = nil
+	return wo, nil
+}
+
+func assignTasks(ctx context.Context, runtime ProcessStarter, wo WorkOrder) error {
+	if runtime == nil || wo.ProcessInstanceID == "" {
+		return nil
+	}
+	assignment := TaskAssignment{
+		Assignee:   wo.Assignee,
+		Candidates: Candidates{Users: wo.CandidateUsers, Groups: wo.CandidateGroups},
+	}
+	if err := runtime.AssignTasks(ctx, wo.ProcessInstanceID, assignment); err != nil {
+		return fmt.Errorf("sync camunda task assignee: %w", err)
+	}
+	return nil
+}
+
+type StartRetrier struct {
+	repo    StartRepository
+	tx      persistence.Transactor
+	starter starter
+	cfg     config.RetryConfig
+}
+
+func NewStartRetrier(repo StartRepository, tx persistence.Transactor, flows FlowReader, runtime ProcessStarter, cfg config.RetryConfig) *StartRetrier {
+	return &StartRetrier{repo: repo, tx: tx, starter: starter{repo: repo, flows: flows, runtime: runtime, retry: cfg}, cfg: cfg}
+}
+
+func (r *StartRetrier) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.RetryOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("workorder start retry: %v", err)
+		}
+		if n == r.cfg.BatchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *StartRetrier) RetryOnce(ctx context.Context) (int, error) {
+	var processed int
+	var failures []error
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		orders, err := r.repo.LockStartDue(ctx, time.Now().UTC(), r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, wo := range orders {
+			ctx := WithActor(tenant.WithTenant(ctx, wo.TenantID), syncActor)
+			var started WorkOrder
+			err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+				var err error
+				started, err = r.starter.start(ctx, wo)
+				if IsStartFailed(err) {
+					log.Printf("workorder start retry: %v", err)
+					return nil
+				}
+				return err
+			})
+			if err != nil {
+				failures = append(failures, fmt.Errorf("%s: %w", wo.ID, err))
+				next := time.Now().UTC().Add(r.cfg.Delay(wo.StartAttempts))
+				if _, markErr := r.repo.MarkStartFailed(ctx, wo.ID, err.Error(), next, r.cfg.MaxAttempts); markErr != nil && !errors.Is(markErr, sqlErrNotFound) {
+					failures = append(failures, fmt.Errorf("%s: %w", wo.ID, markErr))
+				}
+				continue
+			}
+			if started.Assignee != "" || len(started.CandidateUsers) > 0 || len(started.CandidateGroups) > 0 {
+				if err := assignTasks(ctx, r.starter.runtime, started); err != nil {
+					log.Printf("workorder start retry: %s: %v", wo.ID, err)
+				}
+			}
+		}
+
+		processed = len(orders)
+		return nil
+	})
+	if err != nil {
+		return processed, err
+	}
+	return processed, errors.Join(failures...)
+}
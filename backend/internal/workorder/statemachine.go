@@ -0,0 +1,131 @@
+package workorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var transitions = map[Status][]Status{
+	StatusPending:   {StatusRunning, StatusFailed, StatusComplete, StatusCancelled},
+	StatusRunning:   {StatusFailed, StatusComplete, StatusCancelled, StatusSuspended},
+	StatusFailed:    {StatusRunning, StatusComplete, StatusCancelled},
+	StatusSuspended: {StatusRunning, StatusCancelled},
+	StatusComplete:  {},
+	StatusCancelled: {},
+}
+
+func CanTransition(from, to Status) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Status) Terminal() bool {
+	allowed, ok := transitions[s]
+	return ok && len(allowed) == 0
+}
+
+type Transition struct {
+	ID          int64     `json:"id" db:"id"`
+	WorkOrderID string    `json:"workOrderId" db:"workorder_id"`
+	From        Status    `json:"from" db:"from_status"`
+	To          Status    `json:"to" db:"to_status"`
+	Reason      string    `json:"reason" db:"reason"`
+	Actor       string    `json:"actor" db:"actor"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+type TransitionError struct {
+	ID   string
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("workorder %s cannot move from %s to %s", e.ID, e.From, e.To)
+}
+
+func IsInvalidTransition(err error) bool {
+	var target *TransitionError
+	return errors.As(err, &target)
+}
+
+type transitionRepository interface {
+	Transition(ctx context.Context, t Transition) error
+}
+
+func applyTransition(ctx context.Context, repo transitionRepository, publisher StatusPublisher, wo WorkOrder, to Status, reason string) (WorkOrder, error) {
+	if !CanTransition(wo.Status, to) {
+		return WorkOrder{}, &TransitionError{ID: wo.ID, From: wo.Status, To: to}
+	}
+
+	t := Transition{
+		WorkOrderID: wo.ID,
+		From:        wo.Status,
+		To:          to,
+		Reason:      reason,
+		Actor:       ActorFromContext(ctx),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := repo.Transition(ctx, t); err != nil {
+		switch {
+		case errors.Is(err, sqlErrStatusConflict):
+			return WorkOrder{}, &TransitionError{ID: wo.ID, From: wo.Status, To: to}
+		case errors.Is(err, sqlErrNotFound):
+			return WorkOrder{}, notFoundError{id: wo.ID}
+		}
+		return WorkOrder{}, err
+	}
+
+	wo.Status = to
+	wo.UpdatedAt = t.CreatedAt
+	if to == StatusComplete && wo.SLAState.Open() {
+		wo.SLAState = SLAMet
+	}
+
+	if publisher == nil {
+		return wo, nil
+	}
+
+	var err error
+	switch to {
+	case StatusRunning:
+		if t.From == StatusSuspended {
+			err = publisher.PublishWorkOrderResumed(ctx, wo)
+		} else {
+			err = publisher.PublishWorkOrderRunning(ctx, wo)
+		}
+	case StatusSuspended:
+		err = publisher.PublishWorkOrderSuspended(ctx, wo)
+	case StatusFailed:
+		err = publisher.PublishWorkOrderFailed(ctx, wo)
+	case StatusComplete:
+		err = publisher.PublishWorkOrderCompleted(ctx, wo)
+	case StatusCancelled:
+		err = publisher.PublishWorkOrderCancelled(ctx, wo)
+	}
+	if err != nil {
+		return WorkOrder{}, fmt.Errorf("publish %s: %w", to, err)
+	}
+	return wo, nil
+}
+
+const SystemActor = "system"
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
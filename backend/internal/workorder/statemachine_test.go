@@ -0,0 +1,144 @@
+package workorder
+
+import (
+	"context"
+	"testing"
+)
+
+var statuses = []Status{StatusPending, StatusRunning, StatusFailed, StatusSuspended, StatusComplete, StatusCancelled}
+
+func TestCanTransition(t *testing.T) {
+	allowed := map[Status]map[Status]bool{
+		StatusPending:   {StatusRunning: true, StatusFailed: true, StatusComplete: true, StatusCancelled: true},
+		StatusRunning:   {StatusFailed: true, StatusComplete: true, StatusCancelled: true, StatusSuspended: true},
+		StatusFailed:    {StatusRunning: true, StatusComplete: true, StatusCancelled: true},
+		StatusSuspended: {StatusRunning: true, StatusCancelled: true},
+		StatusComplete:  {},
+		StatusCancelled: {},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if got, want := CanTransition(from, to), allowed[from][to]; got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+
+	if CanTransition("archived", StatusRunning) {
+		t.Error("CanTransition from an unknown status must be false")
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	for _, s := range statuses {
+		want := s == StatusComplete || s == StatusCancelled
+		if got := s.Terminal(); got != want {
+			t.Errorf("%s.Terminal() = %v, want %v", s, got, want)
+		}
+	}
+	if Status("archived").Terminal() {
+		t.Error("unknown status must not be terminal")
+	}
+}
+
+type transitionRepo struct {
+	err         error
+	transitions []Transition
+}
+
+func (r *transitionRepo) Transition(_ context.Context, t Transition) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.transitions = append(r.transitions, t)
+	return nil
+}
+
+type statusEvents struct{ events []string }
+
+func (p *statusEvents) record(event string) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *statusEvents) PublishWorkOrderRunning(context.Context, WorkOrder) error {
+	return p.record("running")
+}
+
+func (p *statusEvents) PublishWorkOrderFailed(context.Context, WorkOrder) error {
+	return p.record("failed")
+}
+
+func (p *statusEvents) PublishWorkOrderCompleted(context.Context, WorkOrder) error {
+	return p.record("completed")
+}
+
+func (p *statusEvents) PublishWorkOrderCancelled(context.Context, WorkOrder) error {
+	return p.record("cancelled")
+}
+
+func (p *statusEvents) PublishWorkOrderSuspended(context.Context, WorkOrder) error {
+	return p.record("suspended")
+}
+
+func (p *statusEvents) PublishWorkOrderResumed(context.Context, WorkOrder) error {
+	return p.record("resumed")
+}
+
+func TestApplyTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    Status
+		to      Status
+		repoErr error
+		event   string
+		check   func(error) bool
+	}{
+		{name: "start", from: StatusPending, to: StatusRunning, event: "running"},
+		{name: "suspend", from: StatusRunning, to: StatusSuspended, event: "suspended"},
+		{name: "resume", from: StatusSuspended, to: StatusRunning, event: "resumed"},
+		{name: "retry failed", from: StatusFailed, to: StatusRunning, event: "running"},
+		{name: "complete", from: StatusRunning, to: StatusComplete, event: "completed"},
+		{name: "cancel", from: StatusPending, to: StatusCancelled, event: "cancelled"},
+		{name: "reopen completed", from: StatusComplete, to: StatusRunning, check: IsInvalidTransition},
+		{name: "suspend pending", from: StatusPending, to: StatusSuspended, check: IsInvalidTransition},
+		{name: "concurrent change", from: StatusRunning, to: StatusFailed, repoErr: sqlErrStatusConflict, check: IsInvalidTransition},
+		{name: "deleted", from: StatusRunning, to: StatusFailed, repoErr: sqlErrNotFound, check: IsNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &transitionRepo{err: tt.repoErr}
+			publisher := &statusEvents{}
+			ctx := WithActor(context.Background(), "alice")
+
+			wo, err := applyTransition(ctx, repo, publisher, WorkOrder{ID: "wo-1", Status: tt.from, SLAState: SLAOnTrack}, tt.to, "test")
+			if tt.check != nil {
+				if !tt.check(err) {
+					t.Fatalf("applyTransition error = %v", err)
+				}
+				if len(publisher.events) > 0 {
+					t.Errorf("published %v for a rejected transition", publisher.events)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyTransition: %v", err)
+			}
+
+			if wo.Status != tt.to {
+				t.Errorf("status = %s, want %s", wo.Status, tt.to)
+			}
+			if len(repo.transitions) != 1 || repo.transitions[0].From != tt.from || repo.transitions[0].Actor != "alice" {
+				t.Errorf("recorded transitions = %+v", repo.transitions)
+			}
+			if len(publisher.events) != 1 || publisher.events[0] != tt.event {
+				t.Errorf("published %v, want [%s]", publisher.events, tt.event)
+			}
+			if tt.to == StatusComplete && wo.SLAState != SLAMet {
+				t.Errorf("sla state = %s, want %s", wo.SLAState, SLAMet)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package workorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kyeliu99/Pflow_v2/backend/internal/persistence"
+	"github.com/kyeliu99/Pflow_v2/backend/internal/tenant"
+)
+
+type SyncRepository interface {
+	ClaimTracked(ctx context.Context, before time.Time, limit int) ([]WorkOrder, error)
+	Get(ctx context.Context, id string) (WorkOrder, error)
+	processStateRepository
+}
+
+type processStateRepository interface {
+	transitionRepository
+	assignmentRepository
+	UpdateCurrentStep(ctx context.Context, id, step string) (WorkOrder, error)
+}
+
+type ProcessInspector interface {
+	InspectProcess(ctx context.Context, processInstanceID string) (ProcessState, error)
+}
+
+type StatusPublisher interface {
+	PublishWorkOrderRunning(ctx context.Context, wo WorkOrder) error
+	PublishWorkOrderFailed(ctx context.Context, wo WorkOrder) error
+	PublishWorkOrderCompleted(ctx context.Context, wo WorkOrder) error
+	PublishWorkOrderCancelled(ctx context.Context, wo WorkOrder) error
+	PublishWorkOrderSuspended(ctx context.Context, wo WorkOrder) error
+	PublishWorkOrderResumed(ctx context.Context, wo WorkOrder) error
+}
+
+type SyncPublisher interface {
+	StatusPublisher
+	AssignmentPublisher
+}
+
+const syncActor = "camunda-sync"
+
+type Synchronizer struct {
+	repo      SyncRepository
+	tx        persistence.Transactor
+	runtime   ProcessInspector
+	publisher SyncPublisher
+	interval  time.Duration
+	batchSize int
+}
+
+func NewSynchronizer(repo SyncRepository, tx persistence.Transactor, runtime ProcessInspector, publisher SyncPublisher, interval time.Duration, batchSize int) *Synchronizer {
+	return &Synchronizer{repo: repo, tx: tx, runtime: runtime, publisher: publisher, interval: interval, batchSize: batchSize}
+}
+
+func (s *Synchronizer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.SyncOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("workorder sync: %v", err)
+		}
+		if n == s.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Synchronizer) SyncOnce(ctx context.Context) (int, error) {
+	orders, err := s.repo.ClaimTracked(ctx, time.Now().UTC().Add(-s.interval), s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, wo := range orders {
+		if err := s.sync(WithActor(tenant.WithTenant(ctx, wo.TenantID), syncActor), wo); err != nil {
+			log.Printf("workorder sync: %s: %v", wo.ID, err)
+		}
+	}
+	return len(orders), nil
+}
+
+func (s *Synchronizer) sync(ctx context.Context, wo WorkOrder) error {
+	state, err := s.runtime.InspectProcess(ctx, wo.ProcessInstanceID)
+	if err != nil {
+		return fmt.Errorf("inspect process: %w", err)
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.repo.Get(ctx, wo.ID)
+		if err != nil {
+			return err
+		}
+		if current.ProcessInstanceID != wo.ProcessInstanceID {
+			return nil
+		}
+		_, err = applyProcessState(ctx, s.repo, s.publisher, current, state)
+		return err
+	})
+}
+
+func applyProcessState(ctx context.Context, repo processStateRepository, publisher SyncPublisher, wo WorkOrder, state ProcessState) (WorkOrder, error) {
+	wo, err := syncAssignee(ctx, repo, publisher, wo, state)
+	if err != nil {
+		return WorkOrder{}, fmt.Errorf("sync assignee: %w", err)
+	}
+
+	if step := currentStep(state); step != wo.CurrentStep {
+		if wo, err = repo.UpdateCurrentStep(ctx, wo.ID, step); err != nil {
+			if errors.Is(err, sqlErrNotFound) {
+				return WorkOrder{}, notFoundError{id: wo.ID}
+			}
+			return WorkOrder{}, fmt.Errorf("sync current step: %w", err)
+		}
+	}
+
+	next := statusFromProcess(state)
+	if next == wo.Status {
+		return wo, nil
+	}
+	if !CanTransition(wo.Status, next) {
+		return wo, fmt.Errorf("ignoring process state %s: %w", state.State, &TransitionError{ID: wo.ID, From: wo.Status, To: next})
+	}
+
+	reason := "camunda process " + strings.ToLower(state.State)
+	if len(state.Incidents) > 0 {
+		reason = "camunda incident: " + state.Incidents[0].Message
+	}
+
+	return applyTransition(ctx, repo, publisher, wo, next, reason)
+}
+
+func syncAssignee(ctx context.Context, repo assignmentRepository, publisher AssignmentPublisher, wo WorkOrder, state ProcessState) (WorkOrder, error) {
+	for _, task := range state.UserTasks {
+		if task.Assignee == "" || task.Assignee == wo.Assignee {
+			continue
+		}
+		return recordAssignment(ctx, repo, publisher, wo, task.Assignee, AssignmentSync, "camunda task "+task.Name)
+	}
+	return wo, nil
+}
+
+func currentStep(state ProcessState) string {
+	switch {
+	case state.Ended:
+		return ""
+	case len(state.UserTasks) > 0:
+		return state.UserTasks[0].ActivityID
+	case len(state.ActiveActivities) > 0:
+		return state.ActiveActivities[0]
+	default:
+		return ""
+	}
+}
+
+func statusFromProcess(state ProcessState) Status {
+	switch {
+	case state.State == "COMPLETED":
+		return StatusComplete
+	case state.State == "EXTERNALLY_TERMINATED":
+		return StatusCancelled
+	case state.Ended:
+		return StatusFailed
+	case len(state.Incidents) > 0:
+		return StatusFailed
+	case state.Suspended:
+		return StatusSuspended
+	default:
+		return StatusRunning
+	}
+}
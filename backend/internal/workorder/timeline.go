@@ -0,0 +1,102 @@
+package workorder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type TimelineKind string
+
+const (
+	TimelineComment           TimelineKind = "comment"
+	TimelineTransition        TimelineKind = "transition"
+	TimelineAssignment        TimelineKind = "assignment"
+	TimelineActivityStarted   TimelineKind = "activity_started"
+	TimelineActivityCompleted TimelineKind = "activity_completed"
+	TimelineActivityCancelled TimelineKind = "activity_cancelled"
+)
+
+type Activity struct {
+	ID         string     `json:"id"`
+	ActivityID string     `json:"activityId"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Assignee   string     `json:"assignee,omitempty"`
+	TaskID     string     `json:"taskId,omitempty"`
+	StartTime  time.Time  `json:"startTime"`
+	EndTime    *time.Time `json:"endTime,omitempty"`
+	Cancelled  bool       `json:"cancelled"`
+}
+
+type TimelineEntry struct {
+	Kind       TimelineKind `json:"kind"`
+	At         time.Time    `json:"at"`
+	Actor      string       `json:"actor,omitempty"`
+	Comment    *Comment     `json:"comment,omitempty"`
+	Transition *Transition  `json:"transition,omitempty"`
+	Assignment *Assignment  `json:"assignment,omitempty"`
+	Activity   *Activity    `json:"activity,omitempty"`
+}
+
+func (s *service) Timeline(ctx context.Context, id string, descending bool) ([]TimelineEntry, error) {
+	wo, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.repo.ListComments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	transitions, err := s.repo.ListTransitions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.repo.ListAssignments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var activities []Activity
+	if s.runtime != nil && wo.ProcessInstanceID != "" {
+		if activities, err = s.runtime.ActivityHistory(ctx, wo.ProcessInstanceID); err != nil {
+			return nil, fmt.Errorf("load activity history: %w", err)
+		}
+	}
+
+	entries := make([]TimelineEntry, 0, len(comments)+len(transitions)+len(assignments)+2*len(activities))
+	for i := range comments {
+		c := comments[i]
+		entries = append(entries, TimelineEntry{Kind: TimelineComment, At: c.CreatedAt, Actor: c.Author, Comment: &c})
+	}
+	for i := range transitions {
+		t := transitions[i]
+		entries = append(entries, TimelineEntry{Kind: TimelineTransition, At: t.CreatedAt, Actor: t.Actor, Transition: &t})
+	}
+	for i := range assignments {
+		a := assignments[i]
+		entries = append(entries, TimelineEntry{Kind: TimelineAssignment, At: a.CreatedAt, Actor: a.Actor, Assignment: &a})
+	}
+	for i := range activities {
+		a := activities[i]
+		entries = append(entries, TimelineEntry{Kind: TimelineActivityStarted, At: a.StartTime, Activity: &a})
+		if a.EndTime == nil {
+			continue
+		}
+		kind := TimelineActivityCompleted
+		if a.Cancelled {
+			kind = TimelineActivityCancelled
+		}
+		entries = append(entries, TimelineEntry{Kind: kind, At: *a.EndTime, Actor: a.Assignee, Activity: &a})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if descending {
+			return entries[i].At.After(entries[j].At)
+		}
+		return entries[i].At.Before(entries[j].At)
+	})
+	return entries, nil
+}
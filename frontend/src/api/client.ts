@@ -1,5 +1,20 @@
 import axios from "axios";
 
+export const tokenStorageKey = "pflow.token";
+export const tenantStorageKey = "pflow.tenant";
+
 export const apiClient = axios.create({
   baseURL: "/api"
 });
+
+apiClient.interceptors.request.use((config) => {
+  const token = window.localStorage.getItem(tokenStorageKey);
+  if (token) {
+    config.headers.Authorization = `Bearer ${token}`;
+  }
+  const tenant = window.localStorage.getItem(tenantStorageKey);
+  if (tenant) {
+    config.headers["X-Tenant-ID"] = tenant;
+  }
+  return config;
+});
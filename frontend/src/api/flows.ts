@@ -5,16 +5,45 @@ export interface FlowDefinition {
   edges: Array<Record<string, unknown>>;
 }
 
+export type FlowStatus = "draft" | "published" | "deprecated";
+
+export type DeployStatus = "none" | "pending" | "deployed" | "failed";
+
 export interface Flow {
   id: string;
+  tenantId: string;
   name: string;
   description: string;
   definition: FlowDefinition;
   metadata: Record<string, string>;
   version: number;
+  status: FlowStatus;
+  publishedVersion?: number;
+  deployStatus: DeployStatus;
+  deployAttempts?: number;
+  deployError?: string;
+  nextDeployAt?: string;
+  deployedAt?: string;
   updatedAt: string;
 }
 
+export type FlowSummary = Omit<Flow, "definition">;
+
+export interface FlowPage {
+  items: FlowSummary[];
+  nextCursor?: string;
+}
+
+export interface ListFlowsParams {
+  q?: string;
+  status?: FlowStatus[];
+  deployStatus?: DeployStatus[];
+  sort?: "updatedAt" | "createdAt" | "name";
+  order?: "asc" | "desc";
+  limit?: number;
+  cursor?: string;
+}
+
 export interface CreateFlowInput {
   name: string;
   description: string;
@@ -22,12 +51,150 @@ export interface CreateFlowInput {
   metadata: Record<string, string>;
 }
 
-export const listFlows = async (): Promise<Flow[]> => {
-  const response = await apiClient.get<Flow[]>("/flows");
+export interface FlowProblem {
+  code: string;
+  nodeId?: string;
+  edgeId?: string;
+  message: string;
+}
+
+export interface FlowValidationResult {
+  valid: boolean;
+  problems: FlowProblem[];
+}
+
+export const listFlowPage = async (params: ListFlowsParams = {}): Promise<FlowPage> => {
+  const response = await apiClient.get<FlowPage>("/flows", {
+    params: { ...params, status: params.status?.join(","), deployStatus: params.deployStatus?.join(",") }
+  });
   return response.data;
 };
 
+export const listFlows = async (): Promise<FlowSummary[]> => {
+  const page = await listFlowPage();
+  return page.items;
+};
+
 export const createFlow = async (payload: CreateFlowInput): Promise<Flow> => {
   const response = await apiClient.post<Flow>("/flows", payload);
   return response.data;
 };
+
+export const publishFlow = async (id: string, version?: number): Promise<Flow> => {
+  const response = await apiClient.post<Flow>(`/flows/${id}/publish`, version ? { version } : undefined);
+  return response.data;
+};
+
+export const deprecateFlow = async (id: string): Promise<Flow> => {
+  const response = await apiClient.post<Flow>(`/flows/${id}/deprecate`);
+  return response.data;
+};
+
+export const redeployFlow = async (id: string): Promise<Flow> => {
+  const response = await apiClient.post<Flow>(`/flows/${id}/deploy`);
+  return response.data;
+};
+
+export const validateFlow = async (definition: FlowDefinition): Promise<FlowValidationResult> => {
+  const response = await apiClient.post<FlowValidationResult>("/flows/validate", { definition });
+  return response.data;
+};
+
+export interface MigrationInstruction {
+  source: string;
+  target: string;
+  auto: boolean;
+}
+
+export interface MigrationPlanInput {
+  sourceVersion: number;
+  targetVersion: number;
+  overrides?: Record<string, string>;
+  workOrderIds?: string[];
+}
+
+export interface MigrationPlan {
+  flowId: string;
+  sourceVersion: number;
+  targetVersion: number;
+  sourceDefinitionId: string;
+  targetDefinitionId: string;
+  instructions: MigrationInstruction[];
+  unmapped: string[];
+  problems: string[];
+}
+
+export interface MigrationCandidate {
+  workOrderId: string;
+  title: string;
+  status: string;
+  currentStep: string;
+  processInstanceId: string;
+  migratable: boolean;
+  reason?: string;
+}
+
+export interface MigrationPreview {
+  plan: MigrationPlan;
+  total: number;
+  migratable: number;
+  workOrders: MigrationCandidate[];
+}
+
+export type MigrationItemStatus = "pending" | "migrated" | "failed" | "skipped";
+
+export interface MigrationItem {
+  index: number;
+  workOrderId: string;
+  processInstanceId: string;
+  currentStep: string;
+  batch: number;
+  status: MigrationItemStatus;
+  error?: string;
+  updatedAt: string;
+}
+
+export interface FlowMigration {
+  id: string;
+  tenantId: string;
+  flowId: string;
+  sourceVersion: number;
+  targetVersion: number;
+  sourceDefinitionId: string;
+  targetDefinitionId: string;
+  instructions: MigrationInstruction[];
+  status: "queued" | "running" | "completed" | "partially_failed" | "failed";
+  total: number;
+  migrated: number;
+  failed: number;
+  skipped: number;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+  finishedAt?: string;
+  items?: MigrationItem[];
+}
+
+export const previewFlowMigration = async (id: string, input: MigrationPlanInput): Promise<MigrationPreview> => {
+  const response = await apiClient.post<MigrationPreview>(`/flows/${id}/migrations/preview`, input);
+  return response.data;
+};
+
+export const startFlowMigration = async (id: string, input: MigrationPlanInput): Promise<FlowMigration> => {
+  const response = await apiClient.post<FlowMigration>(`/flows/${id}/migrations`, input);
+  return response.data;
+};
+
+export const listFlowMigrations = async (id: string): Promise<FlowMigration[]> => {
+  const response = await apiClient.get<FlowMigration[]>(`/flows/${id}/migrations`);
+  return response.data;
+};
+
+export const getFlowMigration = async (
+  id: string,
+  migrationId: string,
+  itemStatus?: MigrationItemStatus
+): Promise<FlowMigration> => {
+  const response = await apiClient.get<FlowMigration>(`/flows/${id}/migrations/${migrationId}`, { params: { itemStatus } });
+  return response.data;
+};
@@ -0,0 +1,44 @@
+import { apiClient } from "./client";
+import { WorkOrderPriority } from "./workorders";
+
+export interface SLAPolicy {
+  id: string;
+  tenantId: string;
+  flowId?: string;
+  priority?: WorkOrderPriority;
+  resolution: string;
+  warning: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface SLAPolicyInput {
+  flowId?: string;
+  priority?: WorkOrderPriority;
+  resolution: string | number;
+  warning?: string | number;
+}
+
+export const listSLAPolicies = async (): Promise<SLAPolicy[]> => {
+  const response = await apiClient.get<SLAPolicy[]>("/sla-policies");
+  return response.data;
+};
+
+export const getSLAPolicy = async (id: string): Promise<SLAPolicy> => {
+  const response = await apiClient.get<SLAPolicy>(`/sla-policies/${id}`);
+  return response.data;
+};
+
+export const createSLAPolicy = async (input: SLAPolicyInput): Promise<SLAPolicy> => {
+  const response = await apiClient.post<SLAPolicy>("/sla-policies", input);
+  return response.data;
+};
+
+export const updateSLAPolicy = async (id: string, input: SLAPolicyInput): Promise<SLAPolicy> => {
+  const response = await apiClient.put<SLAPolicy>(`/sla-policies/${id}`, input);
+  return response.data;
+};
+
+export const deleteSLAPolicy = async (id: string): Promise<void> => {
+  await apiClient.delete(`/sla-policies/${id}`);
+};
@@ -0,0 +1,88 @@
+import { apiClient } from "./client";
+import { WorkOrder, WorkOrderStatus } from "./workorders";
+
+export type FormFieldType = "string" | "text" | "number" | "integer" | "boolean" | "date" | "enum";
+
+export interface FormField {
+  name: string;
+  label?: string;
+  type: FormFieldType;
+  required?: boolean;
+  options?: string[];
+  min?: number;
+  max?: number;
+  minLength?: number;
+  maxLength?: number;
+  pattern?: string;
+}
+
+export interface TaskForm {
+  fields: FormField[];
+}
+
+export interface TaskWorkOrder {
+  id: string;
+  title: string;
+  flowId: string;
+  flowVersion: number;
+  status: WorkOrderStatus;
+  assignee: string;
+  currentStep: string;
+}
+
+export interface UserTask {
+  id: string;
+  name: string;
+  activityId: string;
+  assignee?: string;
+  processInstanceId: string;
+  tenantId?: string;
+  priority: number;
+  createdAt: string;
+  due?: string;
+  workOrder?: TaskWorkOrder;
+  form?: TaskForm;
+}
+
+export interface TaskPage {
+  items: UserTask[];
+  nextOffset?: number;
+}
+
+export interface ListTasksParams {
+  scope?: "mine" | "assigned" | "candidate" | "unassigned";
+  assignee?: string;
+  group?: string;
+  limit?: number;
+  offset?: number;
+}
+
+export interface TaskFieldError {
+  field: string;
+  message: string;
+}
+
+export interface TaskCompletion {
+  task: UserTask;
+  workOrder: WorkOrder;
+}
+
+export const listTasks = async (params: ListTasksParams = {}): Promise<TaskPage> => {
+  const response = await apiClient.get<TaskPage>("/tasks", { params });
+  return response.data;
+};
+
+export const getTask = async (id: string): Promise<UserTask> => {
+  const response = await apiClient.get<UserTask>(`/tasks/${id}`);
+  return response.data;
+};
+
+export const claimTask = async (id: string): Promise<UserTask> => {
+  const response = await apiClient.post<UserTask>(`/tasks/${id}/claim`);
+  return response.data;
+};
+
+export const completeTask = async (id: string, variables: Record<string, unknown>): Promise<TaskCompletion> => {
+  const response = await apiClient.post<TaskCompletion>(`/tasks/${id}/complete`, { variables });
+  return response.data;
+};
@@ -1,13 +1,36 @@
 import { apiClient } from "./client";
 
-export type WorkOrderStatus = "pending" | "running" | "failed" | "complete";
+export type WorkOrderStatus = "pending" | "running" | "failed" | "complete" | "cancelled" | "suspended";
+
+export type WorkOrderPriority = "low" | "normal" | "high" | "urgent";
+
+export type SLAState = "none" | "on_track" | "at_risk" | "breached" | "met";
+
+export type StartStatus = "none" | "pending" | "started" | "failed";
 
 export interface WorkOrder {
   id: string;
+  tenantId: string;
   flowId: string;
+  flowVersion: number;
+  externalId?: string;
   title: string;
   assignee: string;
+  candidateUsers: string[];
+  candidateGroups: string[];
   status: WorkOrderStatus;
+  currentStep: string;
+  priority: WorkOrderPriority;
+  slaState: SLAState;
+  dueAt?: string;
+  slaWarningAt?: string;
+  startStatus: StartStatus;
+  startAttempts: number;
+  startError?: string;
+  nextStartAt?: string;
+  processInstanceId?: string;
+  processDefinitionId?: string;
+  businessKey?: string;
   payload: Record<string, unknown>;
   metadata: Record<string, string>;
   createdAt: string;
@@ -16,22 +39,270 @@ export interface WorkOrder {
 
 export interface CreateWorkOrderInput {
   flowId: string;
+  externalId?: string;
   title: string;
+  priority?: WorkOrderPriority;
   assignee?: string;
+  candidateUsers?: string[];
+  candidateGroups?: string[];
   payload?: Record<string, unknown>;
   metadata?: Record<string, string>;
 }
 
-export const listWorkOrders = async (): Promise<WorkOrder[]> => {
-  const response = await apiClient.get<WorkOrder[]>("/workorders");
+export interface WorkOrderPage {
+  items: WorkOrder[];
+  nextCursor?: string;
+}
+
+export interface ListWorkOrdersParams {
+  status?: WorkOrderStatus[];
+  flowId?: string;
+  flowVersion?: number;
+  assignee?: string;
+  priority?: WorkOrderPriority[];
+  slaState?: SLAState[];
+  startStatus?: StartStatus[];
+  dueAfter?: string;
+  dueBefore?: string;
+  sort?: "createdAt" | "updatedAt";
+  order?: "asc" | "desc";
+  limit?: number;
+  cursor?: string;
+}
+
+export const listWorkOrderPage = async (params: ListWorkOrdersParams = {}): Promise<WorkOrderPage> => {
+  const response = await apiClient.get<WorkOrderPage>("/workorders", {
+    params: {
+      ...params,
+      status: params.status?.join(","),
+      priority: params.priority?.join(","),
+      slaState: params.slaState?.join(","),
+      startStatus: params.startStatus?.join(",")
+    }
+  });
   return response.data;
 };
 
-export const createWorkOrder = async (payload: CreateWorkOrderInput): Promise<WorkOrder> => {
-  const response = await apiClient.post<WorkOrder>("/workorders", payload);
+export const listWorkOrders = async (): Promise<WorkOrder[]> => {
+  const page = await listWorkOrderPage();
+  return page.items;
+};
+
+export const createWorkOrder = async (payload: CreateWorkOrderInput, idempotencyKey?: string): Promise<WorkOrder> => {
+  const response = await apiClient.post<WorkOrder>("/workorders", payload, {
+    headers: idempotencyKey ? { "Idempotency-Key": idempotencyKey } : undefined
+  });
   return response.data;
 };
 
 export const retryWorkOrder = async (id: string): Promise<void> => {
   await apiClient.post(`/workorders/${id}/retry`);
 };
+
+export const cancelWorkOrder = async (id: string, reason?: string): Promise<WorkOrder> => {
+  const response = await apiClient.post<WorkOrder>(`/workorders/${id}/cancel`, { reason });
+  return response.data;
+};
+
+export const suspendWorkOrder = async (id: string, reason?: string): Promise<WorkOrder> => {
+  const response = await apiClient.post<WorkOrder>(`/workorders/${id}/suspend`, { reason });
+  return response.data;
+};
+
+export const resumeWorkOrder = async (id: string, reason?: string): Promise<WorkOrder> => {
+  const response = await apiClient.post<WorkOrder>(`/workorders/${id}/resume`, { reason });
+  return response.data;
+};
+
+export interface WorkOrderAssignment {
+  id: number;
+  workOrderId: string;
+  action: "assign" | "reassign" | "claim" | "release" | "sync" | "candidates";
+  from: string;
+  to: string;
+  reason: string;
+  candidates?: { users: string[]; groups: string[] };
+  actor: string;
+  createdAt: string;
+}
+
+export const assignWorkOrder = async (id: string, assignee: string, reason?: string): Promise<WorkOrder> => {
+  const response = await apiClient.post<WorkOrder>(`/workorders/${id}/assign`, { assignee, reason });
+  return response.data;
+};
+
+export const claimWorkOrder = async (id: string): Promise<WorkOrder> => {
+  const response = await apiClient.post<WorkOrder>(`/workorders/${id}/claim`);
+  return response.data;
+};
+
+export const releaseWorkOrder = async (id: string, reason?: string): Promise<WorkOrder> => {
+  const response = await apiClient.post<WorkOrder>(`/workorders/${id}/release`, { reason });
+  return response.data;
+};
+
+export const listWorkOrderAssignments = async (id: string): Promise<WorkOrderAssignment[]> => {
+  const response = await apiClient.get<WorkOrderAssignment[]>(`/workorders/${id}/assignments`);
+  return response.data;
+};
+
+export interface WorkOrderComment {
+  id: string;
+  workOrderId: string;
+  author: string;
+  body: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface WorkOrderTransition {
+  id: number;
+  workOrderId: string;
+  from: WorkOrderStatus;
+  to: WorkOrderStatus;
+  reason: string;
+  actor: string;
+  createdAt: string;
+}
+
+export interface ProcessActivity {
+  id: string;
+  activityId: string;
+  name: string;
+  type: string;
+  assignee?: string;
+  taskId?: string;
+  startTime: string;
+  endTime?: string;
+  cancelled: boolean;
+}
+
+export interface TimelineEntry {
+  kind: "comment" | "transition" | "assignment" | "activity_started" | "activity_completed" | "activity_cancelled";
+  at: string;
+  actor?: string;
+  comment?: WorkOrderComment;
+  transition?: WorkOrderTransition;
+  assignment?: WorkOrderAssignment;
+  activity?: ProcessActivity;
+}
+
+export const listWorkOrderComments = async (id: string): Promise<WorkOrderComment[]> => {
+  const response = await apiClient.get<WorkOrderComment[]>(`/workorders/${id}/comments`);
+  return response.data;
+};
+
+export const addWorkOrderComment = async (id: string, body: string): Promise<WorkOrderComment> => {
+  const response = await apiClient.post<WorkOrderComment>(`/workorders/${id}/comments`, { body });
+  return response.data;
+};
+
+export const editWorkOrderComment = async (id: string, commentId: string, body: string): Promise<WorkOrderComment> => {
+  const response = await apiClient.put<WorkOrderComment>(`/workorders/${id}/comments/${commentId}`, { body });
+  return response.data;
+};
+
+export const deleteWorkOrderComment = async (id: string, commentId: string): Promise<void> => {
+  await apiClient.delete(`/workorders/${id}/comments/${commentId}`);
+};
+
+export const getWorkOrderTimeline = async (id: string, order: "asc" | "desc" = "asc"): Promise<TimelineEntry[]> => {
+  const response = await apiClient.get<TimelineEntry[]>(`/workorders/${id}/timeline`, { params: { order } });
+  return response.data;
+};
+
+export interface WorkOrderAttachment {
+  id: string;
+  workOrderId: string;
+  fileName: string;
+  contentType: string;
+  size: number;
+  checksum: string;
+  uploadedBy: string;
+  createdAt: string;
+}
+
+export const listWorkOrderAttachments = async (id: string): Promise<WorkOrderAttachment[]> => {
+  const response = await apiClient.get<WorkOrderAttachment[]>(`/workorders/${id}/attachments`);
+  return response.data;
+};
+
+export const uploadWorkOrderAttachment = async (id: string, file: File, checksum?: string): Promise<WorkOrderAttachment> => {
+  const form = new FormData();
+  form.append("file", file);
+  if (checksum) {
+    form.append("checksum", checksum);
+  }
+  const response = await apiClient.post<WorkOrderAttachment>(`/workorders/${id}/attachments`, form);
+  return response.data;
+};
+
+export const deleteWorkOrderAttachment = async (id: string, attachmentId: string): Promise<void> => {
+  await apiClient.delete(`/workorders/${id}/attachments/${attachmentId}`);
+};
+
+export const downloadWorkOrderAttachment = async (id: string, attachmentId: string): Promise<Blob> => {
+  const response = await apiClient.get<Blob>(`/workorders/${id}/attachments/${attachmentId}/content`, {
+    responseType: "blob"
+  });
+  return response.data;
+};
+
+export type BulkAction = "create" | "retry" | "cancel" | "reassign";
+
+export interface BulkJobItem {
+  index: number;
+  workOrderId?: string;
+  status: "pending" | "succeeded" | "failed";
+  error?: string;
+  updatedAt: string;
+}
+
+export interface BulkJob {
+  id: string;
+  tenantId: string;
+  action: BulkAction;
+  status: "queued" | "running" | "completed";
+  total: number;
+  succeeded: number;
+  failed: number;
+  reason?: string;
+  assignee?: string;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+  finishedAt?: string;
+  items?: BulkJobItem[];
+}
+
+export interface BulkActionInput {
+  action: Exclude<BulkAction, "create">;
+  ids?: string[];
+  filter?: {
+    status?: WorkOrderStatus[];
+    flowId?: string;
+    assignee?: string;
+    priority?: WorkOrderPriority[];
+    slaState?: SLAState[];
+    metadata?: Record<string, string>;
+  };
+  reason?: string;
+  assignee?: string;
+}
+
+export const createWorkOrderBatch = async (
+  items: Array<CreateWorkOrderInput & { idempotencyKey?: string }>
+): Promise<BulkJob> => {
+  const response = await apiClient.post<BulkJob>("/workorders:batch", { items });
+  return response.data;
+};
+
+export const submitBulkAction = async (input: BulkActionInput): Promise<BulkJob> => {
+  const response = await apiClient.post<BulkJob>("/workorders:bulk-action", input);
+  return response.data;
+};
+
+export const getBulkJob = async (id: string, itemStatus?: BulkJobItem["status"]): Promise<BulkJob> => {
+  const response = await apiClient.get<BulkJob>(`/jobs/${id}`, { params: { itemStatus } });
+  return response.data;
+};
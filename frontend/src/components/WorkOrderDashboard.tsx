@@ -18,7 +18,9 @@ const statusLabels: Record<string, string> = {
   pending: "待执行",
   running: "执行中",
   failed: "失败",
-  complete: "完成"
+  complete: "完成",
+  cancelled: "已取消",
+  suspended: "已挂起"
 };
 
 function WorkOrderDashboard() {
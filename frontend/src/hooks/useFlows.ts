@@ -1,5 +1,5 @@
 import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
-import { CreateFlowInput, Flow, createFlow, listFlows } from "../api/flows";
+import { CreateFlowInput, FlowSummary, createFlow, listFlows } from "../api/flows";
 
 const flowsKey = ["flows"];
 
@@ -22,7 +22,7 @@ export const useCreateFlow = () => {
 
 export const useFlowOptions = () => {
   const { data } = useFlows();
-  return (data ?? []).map((flow: Flow) => ({
+  return (data ?? []).map((flow: FlowSummary) => ({
     label: `${flow.name} v${flow.version}`,
     value: flow.id
   }));